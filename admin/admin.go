@@ -0,0 +1,171 @@
+// Package admin implements an HTTP handler for inspecting and changing
+// log levels at runtime.
+//
+// A GET request responds with the current levels:
+//
+//	{"level":"info","loggers":{"db":"debug"}}
+//
+// A PUT or POST request with the same structure changes them, named
+// loggers set to null are removed. When "duration" is given, such
+// as "15m", the change is reverted automatically once it has elapsed.
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// afterFunc calls fn after duration d.
+var afterFunc = time.AfterFunc
+
+// state of the levels.
+type state struct {
+	Level   log.Level            `json:"level"`
+	Loggers map[string]log.Level `json:"loggers"`
+}
+
+// request to change levels.
+type request struct {
+	Level    *log.Level            `json:"level"`
+	Loggers  map[string]*log.Level `json:"loggers"`
+	Duration string                `json:"duration"`
+}
+
+// Handler implementation.
+type Handler struct {
+	logger *log.Logger
+	mu     sync.Mutex
+}
+
+// New handler changing the levels of `logger`, or log.Log when nil.
+func New(logger *log.Logger) *Handler {
+	return &Handler{
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger
+	if logger == nil {
+		logger, _ = log.Log.(*log.Logger)
+	}
+
+	if logger == nil {
+		http.Error(w, "logger does not support levels", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		if err := h.update(logger, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state{
+		Level:   logger.GetLevel(),
+		Loggers: log.NamedLevels(),
+	})
+}
+
+// update the levels from the request body.
+func (h *Handler) update(logger *log.Logger, r *http.Request) error {
+	var req request
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("parsing body: %w", err)
+	}
+
+	var d time.Duration
+
+	if req.Duration != "" {
+		v, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return fmt.Errorf("parsing duration: %w", err)
+		}
+
+		if v <= 0 {
+			return fmt.Errorf("duration must be positive")
+		}
+
+		d = v
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var reverts []func()
+
+	if req.Level != nil {
+		reverts = append(reverts, setLevel(logger, *req.Level))
+	}
+
+	for name, l := range req.Loggers {
+		reverts = append(reverts, setNamedLevel(name, l))
+	}
+
+	if d > 0 {
+		afterFunc(d, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			for _, fn := range reverts {
+				fn()
+			}
+		})
+	}
+
+	return nil
+}
+
+// setLevel sets the logger's level, returning a function which
+// restores the previous level unless it has changed since.
+func setLevel(logger *log.Logger, l log.Level) func() {
+	prev := logger.GetLevel()
+	logger.SetLevel(l)
+
+	return func() {
+		if logger.GetLevel() == l {
+			logger.SetLevel(prev)
+		}
+	}
+}
+
+// setNamedLevel sets or removes the level for `name`, returning a function
+// which restores the previous level unless it has changed since.
+func setNamedLevel(name string, l *log.Level) func() {
+	prev, existed := log.NamedLevels()[name]
+
+	if l == nil {
+		log.UnsetNamedLevel(name)
+	} else {
+		log.SetNamedLevel(name, *l)
+	}
+
+	return func() {
+		cur, exists := log.NamedLevels()[name]
+
+		if l == nil && exists || l != nil && (!exists || cur != *l) {
+			return
+		}
+
+		if existed {
+			log.SetNamedLevel(name, prev)
+		} else {
+			log.UnsetNamedLevel(name)
+		}
+	}
+}
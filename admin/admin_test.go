@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/discard"
+)
+
+// serve a request returning the response.
+func serve(h http.Handler, method, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func Test(t *testing.T) {
+	defer log.SetNamedLevelsFromString("")
+
+	l := &log.Logger{
+		Handler: discard.New(),
+		Level:   log.InfoLevel,
+	}
+
+	h := New(l)
+
+	t.Run("get", func(t *testing.T) {
+		w := serve(h, "GET", "")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"level":"info","loggers":{}}`+"\n", w.Body.String())
+	})
+
+	t.Run("put", func(t *testing.T) {
+		w := serve(h, "PUT", `{"level":"warn","loggers":{"db":"debug","http":"error"}}`)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, `{"level":"warn","loggers":{"db":"debug","http":"error"}}`+"\n", w.Body.String())
+		assert.Equal(t, log.WarnLevel, l.GetLevel())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		w := serve(h, "POST", `{"loggers":{"http":null}}`)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, `{"level":"warn","loggers":{"db":"debug"}}`+"\n", w.Body.String())
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		w := serve(h, "PUT", `{"level":"loud"}`)
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, log.WarnLevel, l.GetLevel())
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		w := serve(h, "DELETE", "")
+		assert.Equal(t, 405, w.Code)
+	})
+}
+
+func TestDuration(t *testing.T) {
+	defer log.SetNamedLevelsFromString("")
+
+	var revert func()
+	afterFunc = func(d time.Duration, fn func()) *time.Timer {
+		assert.Equal(t, 5*time.Minute, d)
+		revert = fn
+		return nil
+	}
+	defer func() { afterFunc = time.AfterFunc }()
+
+	l := &log.Logger{
+		Handler: discard.New(),
+		Level:   log.InfoLevel,
+	}
+
+	log.SetNamedLevel("http", log.WarnLevel)
+
+	h := New(l)
+
+	w := serve(h, "PUT", `{"level":"debug","loggers":{"db":"debug","http":"debug"},"duration":"5m"}`)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, log.DebugLevel, l.GetLevel())
+
+	// changed in the meantime, so left alone
+	log.SetNamedLevel("http", log.ErrorLevel)
+
+	revert()
+	assert.Equal(t, log.InfoLevel, l.GetLevel())
+	assert.Equal(t, map[string]log.Level{"http": log.ErrorLevel}, log.NamedLevels())
+
+	w = serve(h, "PUT", `{"level":"debug","duration":"-1s"}`)
+	assert.Equal(t, 400, w.Code)
+}
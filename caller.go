@@ -0,0 +1,57 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// pkgPrefix is the function name prefix for this package, used to skip
+// frames when determining the caller.
+var pkgPrefix = reflect.TypeOf(Logger{}).PkgPath() + "."
+
+// Frame represents a single stack frame.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String returns the frame as "dir/file.go:line".
+func (f Frame) String() string {
+	file := f.File
+
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+
+	return fmt.Sprintf("%s:%d", file, f.Line)
+}
+
+// caller returns the first frame outside of this package, so the
+// depth of calls through Logger, Entry and the package-level
+// functions does not matter.
+func caller() *Frame {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		f, more := frames.Next()
+
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return &Frame{
+				Function: f.Function,
+				File:     f.File,
+				Line:     f.Line,
+			}
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrCloseTimeout is returned when flushing or closing the handler
+// does not complete within CloseTimeout.
+var ErrCloseTimeout = errors.New("timeout closing handler")
+
+// CloseTimeout is the maximum duration to wait for the handler
+// to flush or close.
+var CloseTimeout = 5 * time.Second
+
+// Exit is called by Fatal after the handler is closed.
+var Exit = os.Exit
+
+// FlushHandler flushes `h` if it implements Flusher. Handlers which wrap
+// other handlers should use this to implement Flusher.
+func FlushHandler(h Handler) error {
+	if f, ok := h.(Flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// CloseHandler closes `h` if it implements Closer, otherwise flushes it.
+// Handlers which wrap other handlers should use this to implement Closer.
+func CloseHandler(h Handler) error {
+	if c, ok := h.(Closer); ok {
+		return c.Close()
+	}
+
+	return FlushHandler(h)
+}
+
+// Flush flushes the handler, waiting at most CloseTimeout.
+func (l *Logger) Flush() error {
+	h := l.GetHandler()
+	return withTimeout(func() error {
+		return FlushHandler(h)
+	})
+}
+
+// Close flushes and closes the handler, waiting at most CloseTimeout.
+// Named loggers share their parent's handler, so closing one closes it for
+// all of them.
+func (l *Logger) Close() error {
+	h := l.GetHandler()
+	return withTimeout(func() error {
+		return CloseHandler(h)
+	})
+}
+
+// withTimeout calls fn, returning ErrCloseTimeout if it does not
+// complete within CloseTimeout.
+func withTimeout(fn func() error) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(CloseTimeout):
+		return ErrCloseTimeout
+	}
+}
@@ -0,0 +1,109 @@
+package log_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/level"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/multi"
+)
+
+// closer handler recording calls.
+type closer struct {
+	memory.Handler
+	calls []string
+	err   error
+	delay time.Duration
+}
+
+func (c *closer) Flush() error {
+	c.calls = append(c.calls, "flush")
+	return c.err
+}
+
+func (c *closer) Close() error {
+	time.Sleep(c.delay)
+	c.calls = append(c.calls, "close")
+	return c.err
+}
+
+// flusher handler recording calls.
+type flusher struct {
+	memory.Handler
+	calls []string
+}
+
+func (f *flusher) Flush() error {
+	f.calls = append(f.calls, "flush")
+	return nil
+}
+
+func TestLogger_Close(t *testing.T) {
+	a := &closer{}
+	b := &flusher{}
+
+	l := &log.Logger{
+		Handler: multi.New(a, level.New(b, log.ErrorLevel)),
+		Level:   log.InfoLevel,
+	}
+
+	assert.NoError(t, l.Flush())
+	assert.Equal(t, []string{"flush"}, a.calls)
+	assert.Equal(t, []string{"flush"}, b.calls)
+
+	assert.NoError(t, l.Named("db").Close())
+	assert.Equal(t, []string{"flush", "close"}, a.calls)
+	assert.Equal(t, []string{"flush", "flush"}, b.calls)
+}
+
+func TestLogger_Close_error(t *testing.T) {
+	a := &closer{err: errors.New("boom")}
+	b := &flusher{}
+
+	l := &log.Logger{
+		Handler: multi.New(a, b),
+		Level:   log.InfoLevel,
+	}
+
+	assert.EqualError(t, l.Close(), "*log_test.closer: boom")
+	assert.Equal(t, []string{"flush"}, b.calls)
+}
+
+func TestLogger_Close_timeout(t *testing.T) {
+	log.CloseTimeout = 10 * time.Millisecond
+	defer func() { log.CloseTimeout = 5 * time.Second }()
+
+	l := &log.Logger{
+		Handler: &closer{delay: time.Second},
+		Level:   log.InfoLevel,
+	}
+
+	assert.Equal(t, log.ErrCloseTimeout, l.Close())
+}
+
+func TestLogger_Fatal(t *testing.T) {
+	var code int
+	log.Exit = func(v int) {
+		code = v
+	}
+	defer func() { log.Exit = os.Exit }()
+
+	h := &closer{}
+
+	l := &log.Logger{
+		Handler: h,
+		Level:   log.InfoLevel,
+	}
+
+	l.WithField("file", "sloth.png").Fatal("upload failed")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, []string{"close"}, h.calls)
+	assert.Equal(t, "upload failed", h.Entries[0].Message)
+}
@@ -1,10 +1,18 @@
 package log
 
-import "context"
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // logKey is a private context key.
 type logKey struct{}
 
+// fieldsKey is a private context key.
+type fieldsKey struct{}
+
 // NewContext returns a new context with logger.
 func NewContext(ctx context.Context, v Interface) context.Context {
 	return context.WithValue(ctx, logKey{}, v)
@@ -17,3 +25,144 @@ func FromContext(ctx context.Context) Interface {
 	}
 	return Log
 }
+
+// ContextWithFields returns a new context with `fields` merged into any
+// fields already stored in `ctx`. These are added to entries by WithContext.
+func ContextWithFields(ctx context.Context, fields Fielder) context.Context {
+	f := Fields{}
+
+	for k, v := range FieldsFromContext(ctx) {
+		f[k] = v
+	}
+
+	for k, v := range fields.Fields() {
+		f[k] = v
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// FieldsFromContext returns the fields stored in `ctx`, which must not be modified.
+func FieldsFromContext(ctx context.Context) Fields {
+	f, _ := ctx.Value(fieldsKey{}).(Fields)
+	return f
+}
+
+// Extractor returns fields derived from a context, such as request or trace ids.
+type Extractor func(context.Context) Fields
+
+// extractors registered.
+var extractors struct {
+	mu  sync.Mutex
+	fns atomic.Value // []Extractor
+}
+
+// RegisterExtractor adds an extractor run by WithContext. This is thread-safe.
+func RegisterExtractor(fn Extractor) {
+	extractors.mu.Lock()
+	defer extractors.mu.Unlock()
+
+	fns, _ := extractors.fns.Load().([]Extractor)
+	fns = append(fns[:len(fns):len(fns)], fn)
+	extractors.fns.Store(fns)
+}
+
+// ResetExtractors removes all registered extractors. This is thread-safe.
+func ResetExtractors() {
+	extractors.mu.Lock()
+	defer extractors.mu.Unlock()
+	extractors.fns.Store([]Extractor(nil))
+}
+
+// ValueExtractor returns an extractor which sets the field `name` to the
+// value stored in the context for `key`, when present.
+func ValueExtractor(key interface{}, name string) Extractor {
+	return func(ctx context.Context) Fields {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+
+		return Fields{name: v}
+	}
+}
+
+// TraceparentExtractor returns an extractor which parses the W3C traceparent
+// header value stored in the context for `key`, setting the "trace_id" and
+// "span_id" fields.
+func TraceparentExtractor(key interface{}) Extractor {
+	return func(ctx context.Context) Fields {
+		s, _ := ctx.Value(key).(string)
+
+		traceID, spanID, ok := ParseTraceparent(s)
+		if !ok {
+			return nil
+		}
+
+		return Fields{
+			"trace_id": traceID,
+			"span_id":  spanID,
+		}
+	}
+}
+
+// ParseTraceparent parses a W3C traceparent header value such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+// returning the trace and span ids.
+func ParseTraceparent(s string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+
+	if len(parts) < 4 {
+		return "", "", false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	if !isHex(version, 2) || version == "ff" || version == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", "", false
+	}
+
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+
+	return traceID, spanID, true
+}
+
+// isHex returns true if `s` is `n` lowercase hex digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contextFields returns the fields from registered extractors and those
+// stored in `ctx`, the latter taking precedence.
+func contextFields(ctx context.Context) Fields {
+	f := Fields{}
+
+	fns, _ := extractors.fns.Load().([]Extractor)
+	for _, fn := range fns {
+		for k, v := range fn(ctx) {
+			f[k] = v
+		}
+	}
+
+	for k, v := range FieldsFromContext(ctx) {
+		f[k] = v
+	}
+
+	return f
+}
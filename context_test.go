@@ -7,6 +7,7 @@ import (
 	"github.com/tj/assert"
 
 	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
 )
 
 func TestFromContext(t *testing.T) {
@@ -21,3 +22,79 @@ func TestFromContext(t *testing.T) {
 	logger = log.FromContext(ctx)
 	assert.Equal(t, logs, logger)
 }
+
+type requestIDKey struct{}
+type traceparentKey struct{}
+
+func TestWithContext(t *testing.T) {
+	defer log.ResetExtractors()
+
+	log.RegisterExtractor(log.ValueExtractor(requestIDKey{}, "request_id"))
+	log.RegisterExtractor(log.TraceparentExtractor(traceparentKey{}))
+
+	h := memory.New()
+
+	l := &log.Logger{
+		Handler: h,
+		Level:   log.InfoLevel,
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, requestIDKey{}, "abc")
+	ctx = context.WithValue(ctx, traceparentKey{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = log.ContextWithFields(ctx, log.Fields{"user": "tobi"})
+	ctx = log.ContextWithFields(ctx, log.Fields{"tenant": "apex", "request_id": "xyz"})
+
+	l.WithField("file", "sloth.png").WithContext(ctx).Info("upload")
+	l.WithContext(context.Background()).Info("hello")
+
+	assert.Equal(t, log.Fields{
+		"file":       "sloth.png",
+		"user":       "tobi",
+		"tenant":     "apex",
+		"request_id": "xyz",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+	}, h.Entries[0].Fields)
+
+	assert.Equal(t, log.Fields{}, h.Entries[1].Fields)
+}
+
+// wrapper implements log.Interface without WithContext.
+type wrapper struct {
+	log.Interface
+}
+
+func TestWithContext_interface(t *testing.T) {
+	defer func(v log.Interface) { log.Log = v }(log.Log)
+
+	h := memory.New()
+	log.Log = wrapper{&log.Logger{Handler: h, Level: log.InfoLevel}}
+
+	ctx := log.ContextWithFields(context.Background(), log.Fields{"user": "tobi"})
+	log.WithContext(ctx).Info("hello")
+
+	assert.Equal(t, log.Fields{"user": "tobi"}, h.Entries[0].Fields)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, ok := log.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", spanID)
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+
+	for _, s := range invalid {
+		_, _, ok := log.ParseTraceparent(s)
+		assert.False(t, ok, s)
+	}
+}
@@ -39,6 +39,10 @@ func handleStdLog(e *Entry) error {
 		fmt.Fprintf(&b, " %s=%v", f.Name, f.Value)
 	}
 
+	if e.Caller != nil {
+		fmt.Fprintf(&b, " caller=%s", e.Caller)
+	}
+
 	log.Println(b.String())
 
 	return nil
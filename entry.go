@@ -1,9 +1,8 @@
 package log
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strings"
 	"time"
 )
 
@@ -20,6 +19,7 @@ type Entry struct {
 	Level     Level     `json:"level"`
 	Timestamp time.Time `json:"timestamp"`
 	Message   string    `json:"message"`
+	Caller    *Frame    `json:"caller,omitempty"`
 	start     time.Time
 	fields    []Fields
 }
@@ -56,7 +56,11 @@ func (e *Entry) WithDuration(d time.Duration) *Entry {
 // WithError returns a new entry with the "error" set to `err`.
 //
 // The given error may implement .Fielder, if it does the method
-// will add all its `.Fields()` into the returned entry.
+// will add all its `.Fields()` into the returned entry. Errors wrapped
+// by `err` are inspected as well, with fields of outer errors taking
+// precedence. When an error in the chain has a stack trace the "source"
+// and "stack" fields are set from the innermost one, and when it
+// wraps multiple errors the "errors" field lists each cause.
 func (e *Entry) WithError(err error) *Entry {
 	if err == nil {
 		return e
@@ -64,28 +68,53 @@ func (e *Entry) WithError(err error) *Entry {
 
 	ctx := e.WithField("error", err.Error())
 
-	if s, ok := err.(stackTracer); ok {
-		frame := s.StackTrace()[0]
+	if s := stackOf(err); s != nil {
+		frames := s.StackTrace()
 
-		name := fmt.Sprintf("%n", frame)
-		file := fmt.Sprintf("%+s", frame)
-		line := fmt.Sprintf("%d", frame)
+		if len(frames) > 0 {
+			var stack []Frame
+			for _, f := range frames {
+				stack = append(stack, frameOf(f))
+			}
 
-		parts := strings.Split(file, "\n\t")
-		if len(parts) > 1 {
-			file = parts[1]
+			frame := stack[0]
+			name := fmt.Sprintf("%n", frames[0])
+
+			ctx = ctx.WithFields(Fields{
+				"source": fmt.Sprintf("%s: %s:%d", name, frame.File, frame.Line),
+				"stack":  stack,
+			})
 		}
+	}
 
-		ctx = ctx.WithField("source", fmt.Sprintf("%s: %s:%s", name, file, line))
+	if errs := causesOf(err); len(errs) > 0 {
+		var causes []string
+		for _, err := range errs {
+			causes = append(causes, err.Error())
+		}
+		ctx = ctx.WithField("errors", causes)
 	}
 
-	if f, ok := err.(Fielder); ok {
-		ctx = ctx.WithFields(f.Fields())
+	var fielders []Fielder
+	walkErrors(err, func(err error) {
+		if f, ok := err.(Fielder); ok {
+			fielders = append(fielders, f)
+		}
+	})
+
+	for i := len(fielders) - 1; i >= 0; i-- {
+		ctx = ctx.WithFields(fielders[i].Fields())
 	}
 
 	return ctx
 }
 
+// WithContext returns a new entry with the fields stored in `ctx`
+// and those returned by registered extractors.
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	return e.WithFields(contextFields(ctx))
+}
+
 // Debug level message.
 func (e *Entry) Debug(msg string) {
 	e.Logger.log(DebugLevel, e, msg)
@@ -106,10 +135,15 @@ func (e *Entry) Error(msg string) {
 	e.Logger.log(ErrorLevel, e, msg)
 }
 
-// Fatal level message, followed by an exit.
+// Fatal level message, followed by closing the handler and an exit.
 func (e *Entry) Fatal(msg string) {
 	e.Logger.log(FatalLevel, e, msg)
-	os.Exit(1)
+
+	if err := e.Logger.Close(); err != nil {
+		e.Logger.GetErrorHandler()(NewHandlerError(e.Logger.GetHandler(), err, 0))
+	}
+
+	Exit(1)
 }
 
 // Debugf level formatted message.
@@ -2,9 +2,11 @@ package log
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,6 +51,50 @@ func TestEntry_WithError_fields(t *testing.T) {
 	}, b.mergedFields())
 }
 
+func TestEntry_WithError_wrapped(t *testing.T) {
+	err := fmt.Errorf("uploading: %w", errFields("boom"))
+	err = fielderError{err, Fields{"reason": "unauthorized", "file": "sloth.png"}}
+
+	a := NewEntry(nil)
+	b := a.WithError(err)
+	assert.Equal(t, Fields{
+		"error":  "uploading: boom",
+		"reason": "unauthorized",
+		"file":   "sloth.png",
+	}, b.mergedFields())
+}
+
+func TestEntry_WithError_stack(t *testing.T) {
+	err := errors.New("boom")
+	err = errors.Wrap(err, "uploading")
+	err = fmt.Errorf("handling request: %w", err)
+
+	a := NewEntry(nil)
+	b := a.WithError(err).mergedFields()
+	assert.Equal(t, "handling request: uploading: boom", b["error"])
+
+	stack := b["stack"].([]Frame)
+	assert.True(t, len(stack) > 1)
+	assert.Equal(t, "github.com/apex/log.TestEntry_WithError_stack", stack[0].Function)
+	assert.Contains(t, stack[0].File, "entry_test.go")
+	assert.Equal(t, fmt.Sprintf("TestEntry_WithError_stack: %s:%d", stack[0].File, stack[0].Line), b["source"])
+}
+
+func TestEntry_WithError_multiple(t *testing.T) {
+	err := fmt.Errorf("uploading: %w", multiError{
+		errFields("timeout"),
+		fmt.Errorf("unauthorized"),
+	})
+
+	a := NewEntry(nil)
+	b := a.WithError(err)
+	assert.Equal(t, Fields{
+		"error":  "uploading: timeout; unauthorized",
+		"errors": []string{"timeout", "unauthorized"},
+		"reason": "timeout",
+	}, b.mergedFields())
+}
+
 func TestEntry_WithError_nil(t *testing.T) {
 	a := NewEntry(nil)
 	b := a.WithError(nil)
@@ -62,6 +108,33 @@ func TestEntry_WithDuration(t *testing.T) {
 	assert.Equal(t, Fields{"duration": int64(2000)}, b.mergedFields())
 }
 
+type fielderError struct {
+	error
+	fields Fields
+}
+
+func (e fielderError) Unwrap() error {
+	return e.error
+}
+
+func (e fielderError) Fields() Fields {
+	return e.fields
+}
+
+type multiError []error
+
+func (m multiError) Error() string {
+	var s []string
+	for _, err := range m {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+func (m multiError) Unwrap() []error {
+	return m
+}
+
 type errFields string
 
 func (ef errFields) Error() string {
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	stdlog "log"
+)
+
+// HandlerError is reported when a handler fails to handle entries.
+type HandlerError struct {
+	// Handler is the name of the handler, such as "es".
+	Handler string
+
+	// Entries is the number of entries lost.
+	Entries int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implementation.
+func (e *HandlerError) Error() string {
+	if e.Entries > 1 {
+		return fmt.Sprintf("%s: %d entries lost: %s", e.Handler, e.Entries, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Handler, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// Temporary returns true if the underlying error is temporary.
+func (e *HandlerError) Temporary() bool {
+	t, ok := e.Err.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+// NewHandlerError returns `err` as a *HandlerError for `h` losing `entries`,
+// unless it is one already.
+func NewHandlerError(h Handler, err error, entries int) error {
+	var e *HandlerError
+
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &HandlerError{
+		Handler: fmt.Sprintf("%T", h),
+		Entries: entries,
+		Err:     err,
+	}
+}
+
+// HandleError is the default error handler, outputting to the stdlib log.
+func HandleError(err error) {
+	stdlog.Printf("error logging: %s", err)
+}
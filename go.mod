@@ -4,14 +4,11 @@ go 1.13
 
 require (
 	github.com/apex/logs v1.0.0
-	github.com/aphistic/golf v0.0.0-20180712155816-02c07f170c5a
-	github.com/aphistic/sweet v0.2.0 // indirect
 	github.com/aws/aws-sdk-go v1.20.6
 	github.com/aybabtme/rgbterm v0.0.0-20170906152045-cc83f3b3ce59
 	github.com/fatih/color v1.7.0
 	github.com/go-logfmt/logfmt v0.4.0
 	github.com/golang/protobuf v1.3.1 // indirect
-	github.com/google/uuid v1.1.1 // indirect
 	github.com/jpillora/backoff v0.0.0-20180909062703-3050d21c67d7 // indirect
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.2
@@ -26,6 +23,7 @@ require (
 	github.com/tj/go-kinesis v0.0.0-20171128231115-08b17f58cb1b
 	github.com/tj/go-spin v1.1.0
 	golang.org/x/net v0.0.0-20190620200207-3b0461eec859 // indirect
+	golang.org/x/sys v0.0.0-20190412213103-97732733099d
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/yaml.v2 v2.2.2 // indirect
@@ -30,6 +30,7 @@ type Handler struct {
 	projectID     string
 	httpClient    *http.Client
 	bufferOptions []buffer.Option
+	errorHandler  func(error)
 
 	b *buffer.Buffer
 	c logs.Client
@@ -42,6 +43,7 @@ type Option func(*Handler)
 func New(url, projectID, authToken string, options ...Option) *Handler {
 	var v Handler
 	v.projectID = projectID
+	v.errorHandler = printError
 
 	// options
 	for _, o := range options {
@@ -58,7 +60,7 @@ func New(url, projectID, authToken string, options ...Option) *Handler {
 	// event buffer
 	var o []buffer.Option
 	o = append(o, buffer.WithFlushHandler(v.handleFlush))
-	o = append(o, buffer.WithErrorHandler(v.handleError))
+	o = append(o, buffer.WithErrorHandler(v.errorHandler))
 	o = append(o, v.bufferOptions...)
 	v.b = buffer.New(o...)
 
@@ -79,6 +81,14 @@ func WithBufferOptions(options ...buffer.Option) Option {
 	}
 }
 
+// WithErrorHandler sets the function called with a *log.HandlerError when
+// flushing fails, by default errors are written to stderr.
+func WithErrorHandler(fn func(error)) Option {
+	return func(v *Handler) {
+		v.errorHandler = fn
+	}
+}
+
 // HandleLog implements log.Handler.
 func (h *Handler) HandleLog(e *log.Entry) error {
 	h.b.Push(logs.Event{
@@ -91,9 +101,11 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 	return nil
 }
 
-// Flush any pending logs. This method is non-blocking.
-func (h *Handler) Flush() {
-	h.b.Flush()
+// Flush implements log.Flusher, flushing any pending logs. This method is
+// blocking.
+func (h *Handler) Flush() error {
+	h.FlushSync()
+	return nil
 }
 
 // FlushSync any pending logs. This method is blocking.
@@ -103,9 +115,10 @@ func (h *Handler) FlushSync() {
 
 // Close flushes any pending logs, and waits for flushing to complete. This
 // method should be called before exiting your program to ensure entries have
-// flushed properly.
-func (h *Handler) Close() {
+// flushed properly, which log.Close does for you.
+func (h *Handler) Close() error {
 	h.b.Close()
+	return nil
 }
 
 // handleFlush implementation.
@@ -120,13 +133,23 @@ func (h *Handler) handleFlush(ctx context.Context, values []interface{}) error {
 		return nil
 	}
 
-	return h.c.AddEvents(logs.AddEventsInput{
+	err := h.c.AddEvents(logs.AddEventsInput{
 		ProjectID: h.projectID,
 		Events:    events,
 	})
+
+	if err != nil {
+		return &log.HandlerError{
+			Handler: "apexlogs",
+			Entries: len(events),
+			Err:     err,
+		}
+	}
+
+	return nil
 }
 
-// handleError implementation.
-func (h *Handler) handleError(err error) {
+// printError outputs to stderr.
+func printError(err error) {
 	logger.Printf("error flushing logs: %v", err)
 }
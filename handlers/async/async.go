@@ -0,0 +1,337 @@
+// Package async implements a handler which passes entries to another
+// handler asynchronously through a bounded queue, so that slow handlers
+// do not block the goroutines logging.
+package async
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// ErrClosed is returned when logging to a closed handler.
+var ErrClosed = errors.New("handler closed")
+
+// Policy for handling entries when the queue is full.
+type Policy int
+
+// Policies.
+const (
+	// Block until there is space in the queue.
+	Block Policy = iota
+
+	// DropNewest drops the entry being logged.
+	DropNewest
+
+	// DropOldest drops the oldest entry in the queue to make space.
+	DropOldest
+
+	// DropBelow drops the entry being logged when it is below the level
+	// set with WithDropBelow, otherwise blocks.
+	DropBelow
+)
+
+// Stats for the handler.
+type Stats struct {
+	Queued  int    // Queued is the number of entries currently queued
+	Handled uint64 // Handled is the number of entries passed to the handler
+	Dropped uint64 // Dropped is the number of entries dropped due to overflow
+	Failed  uint64 // Failed is the number of entries the handler returned an error for
+}
+
+// Handler implementation.
+type Handler struct {
+	Handler log.Handler
+
+	size         int
+	workers      int
+	policy       Policy
+	level        log.Level
+	timeout      time.Duration
+	errorHandler func(error)
+
+	queue  chan *log.Entry
+	done   chan struct{}
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	// senders blocked on a full queue, which may still send after done is closed
+	senders sync.WaitGroup
+
+	pmu     sync.Mutex
+	idle    *sync.Cond
+	pending int64
+
+	handled uint64
+	dropped uint64
+	failed  uint64
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler passing entries to `h` asynchronously.
+func New(h log.Handler, options ...Option) *Handler {
+	v := &Handler{
+		Handler:      h,
+		size:         1024,
+		workers:      1,
+		timeout:      5 * time.Second,
+		errorHandler: log.HandleError,
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	v.idle = sync.NewCond(&v.pmu)
+	v.queue = make(chan *log.Entry, v.size)
+	v.done = make(chan struct{})
+
+	for i := 0; i < v.workers; i++ {
+		v.wg.Add(1)
+		go v.work()
+	}
+
+	return v
+}
+
+// WithQueueSize sets the maximum number of queued entries (default: 1024).
+func WithQueueSize(n int) Option {
+	return func(v *Handler) {
+		v.size = n
+	}
+}
+
+// WithWorkers sets the number of goroutines passing entries to the
+// handler (default: 1). Entries are handled in order only with a single worker.
+func WithWorkers(n int) Option {
+	return func(v *Handler) {
+		v.workers = n
+	}
+}
+
+// WithPolicy sets the policy used when the queue is full (default: Block).
+func WithPolicy(p Policy) Option {
+	return func(v *Handler) {
+		v.policy = p
+	}
+}
+
+// WithDropBelow drops entries below `level` when the queue is full,
+// blocking for the others.
+func WithDropBelow(level log.Level) Option {
+	return func(v *Handler) {
+		v.policy = DropBelow
+		v.level = level
+	}
+}
+
+// WithCloseTimeout sets the maximum duration Close waits for queued
+// entries to be handled (default: 5s).
+func WithCloseTimeout(d time.Duration) Option {
+	return func(v *Handler) {
+		v.timeout = d
+	}
+}
+
+// WithErrorHandler sets the function called with a *log.HandlerError when
+// the handler fails, by default errors are written to the stdlib log.
+func WithErrorHandler(fn func(error)) Option {
+	return func(v *Handler) {
+		v.errorHandler = fn
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	ok, err := h.enqueue(e)
+	if ok || err != nil {
+		return err
+	}
+
+	// block without holding the lock, so Close is not delayed. Workers
+	// wait for blocked senders before draining the queue once closed.
+	defer h.senders.Done()
+
+	select {
+	case h.queue <- e:
+		return nil
+	case <-h.done:
+		h.drop()
+		return ErrClosed
+	}
+}
+
+// enqueue queues `e` or drops it according to the policy, without
+// blocking. It returns false when the entry must be queued by blocking.
+func (h *Handler) enqueue(e *log.Entry) (bool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.closed {
+		return false, ErrClosed
+	}
+
+	h.add(1)
+
+	select {
+	case h.queue <- e:
+		return true, nil
+	default:
+	}
+
+	switch h.policy {
+	case DropNewest:
+		h.drop()
+		return true, nil
+	case DropOldest:
+		for {
+			select {
+			case h.queue <- e:
+				return true, nil
+			default:
+			}
+
+			select {
+			case <-h.queue:
+				h.drop()
+			default:
+			}
+		}
+	case DropBelow:
+		if e.Level < h.level {
+			h.drop()
+			return true, nil
+		}
+	}
+
+	h.senders.Add(1)
+	return false, nil
+}
+
+// Stats returns the handler's stats.
+func (h *Handler) Stats() Stats {
+	return Stats{
+		Queued:  len(h.queue),
+		Handled: atomic.LoadUint64(&h.handled),
+		Dropped: atomic.LoadUint64(&h.dropped),
+		Failed:  atomic.LoadUint64(&h.failed),
+	}
+}
+
+// Flush blocks until queued entries have been handled, and flushes the handler.
+func (h *Handler) Flush() error {
+	h.pmu.Lock()
+	for atomic.LoadInt64(&h.pending) > 0 && !h.isClosed() {
+		h.idle.Wait()
+	}
+	h.pmu.Unlock()
+
+	return log.FlushHandler(h.Handler)
+}
+
+// Close stops accepting entries and waits for queued entries to be handled,
+// at most the close timeout, then closes the handler. Entries still queued
+// after the timeout are lost.
+func (h *Handler) Close() error {
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.done)
+	h.mu.Unlock()
+
+	// wake any Flush waiting on entries which will not be handled
+	h.pmu.Lock()
+	h.idle.Broadcast()
+	h.pmu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		return &log.HandlerError{
+			Handler: "async",
+			Entries: len(h.queue),
+			Err:     log.ErrCloseTimeout,
+		}
+	}
+
+	return log.CloseHandler(h.Handler)
+}
+
+// isClosed returns true if the handler is closed.
+func (h *Handler) isClosed() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// work passes queued entries to the handler until closed, then
+// handles the entries remaining in the queue once blocked senders
+// have returned.
+func (h *Handler) work() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case e := <-h.queue:
+			h.handle(e)
+		case <-h.done:
+			h.senders.Wait()
+			for {
+				select {
+				case e := <-h.queue:
+					h.handle(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle passes an entry to the handler.
+func (h *Handler) handle(e *log.Entry) {
+	if err := h.Handler.HandleLog(e); err != nil {
+		atomic.AddUint64(&h.failed, 1)
+		h.errorHandler(log.NewHandlerError(h.Handler, err, 1))
+	}
+
+	atomic.AddUint64(&h.handled, 1)
+	h.add(-1)
+}
+
+// drop records a dropped entry.
+func (h *Handler) drop() {
+	atomic.AddUint64(&h.dropped, 1)
+	h.add(-1)
+}
+
+// add `n` to the number of pending entries, waking Flush when there
+// are none left.
+func (h *Handler) add(n int64) {
+	if atomic.AddInt64(&h.pending, n) == 0 {
+		h.pmu.Lock()
+		h.idle.Broadcast()
+		h.pmu.Unlock()
+	}
+}
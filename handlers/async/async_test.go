@@ -0,0 +1,203 @@
+package async_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/async"
+	"github.com/apex/log/handlers/memory"
+)
+
+// gated handler blocking until the gate is opened.
+type gated struct {
+	memory.Handler
+	gate chan struct{}
+}
+
+func newGated() *gated {
+	return &gated{gate: make(chan struct{})}
+}
+
+func (g *gated) HandleLog(e *log.Entry) error {
+	<-g.gate
+	return g.Handler.HandleLog(e)
+}
+
+// messages returns the entry messages.
+func messages(h *memory.Handler) (v []string) {
+	for _, e := range h.Entries {
+		v = append(v, e.Message)
+	}
+	return
+}
+
+// fill logs messages until the single worker is blocked and the queue is full.
+func fill(t *testing.T, h *async.Handler, msgs ...string) {
+	for _, m := range msgs {
+		assert.NoError(t, h.HandleLog(&log.Entry{Message: m, Level: log.InfoLevel}))
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test(t *testing.T) {
+	h := memory.New()
+	a := async.New(h, async.WithWorkers(4))
+
+	l := log.Logger{
+		Handler: a,
+		Level:   log.InfoLevel,
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Info("upload")
+	}
+
+	assert.NoError(t, a.Flush())
+	assert.Len(t, h.Entries, 100)
+	assert.Equal(t, async.Stats{Handled: 100}, a.Stats())
+
+	assert.NoError(t, a.Close())
+	assert.Equal(t, async.ErrClosed, a.HandleLog(&log.Entry{}))
+}
+
+func TestDropNewest(t *testing.T) {
+	g := newGated()
+	a := async.New(g, async.WithQueueSize(2), async.WithPolicy(async.DropNewest))
+
+	fill(t, a, "a", "b", "c", "d", "e")
+	assert.Equal(t, uint64(2), a.Stats().Dropped)
+
+	close(g.gate)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, []string{"a", "b", "c"}, messages(&g.Handler))
+}
+
+func TestDropOldest(t *testing.T) {
+	g := newGated()
+	a := async.New(g, async.WithQueueSize(2), async.WithPolicy(async.DropOldest))
+
+	fill(t, a, "a", "b", "c", "d", "e")
+	assert.Equal(t, uint64(2), a.Stats().Dropped)
+
+	close(g.gate)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, []string{"a", "d", "e"}, messages(&g.Handler))
+}
+
+func TestDropBelow(t *testing.T) {
+	g := newGated()
+	a := async.New(g, async.WithQueueSize(1), async.WithDropBelow(log.WarnLevel))
+
+	fill(t, a, "a", "b", "c")
+
+	done := make(chan struct{})
+	go func() {
+		a.HandleLog(&log.Entry{Message: "boom", Level: log.ErrorLevel})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected error entry to block")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(g.gate)
+	<-done
+	assert.NoError(t, a.Close())
+	assert.Equal(t, uint64(1), a.Stats().Dropped)
+	assert.Equal(t, []string{"a", "b", "boom"}, messages(&g.Handler))
+}
+
+func TestClose_timeout(t *testing.T) {
+	g := newGated()
+	a := async.New(g, async.WithQueueSize(10), async.WithCloseTimeout(10*time.Millisecond))
+
+	fill(t, a, "a", "b", "c")
+
+	err := a.Close()
+	var e *log.HandlerError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, 2, e.Entries)
+	assert.Equal(t, log.ErrCloseTimeout, errors.Unwrap(err))
+	close(g.gate)
+}
+
+func TestClose_blocked(t *testing.T) {
+	g := newGated()
+	a := async.New(g, async.WithQueueSize(1), async.WithCloseTimeout(10*time.Millisecond))
+
+	fill(t, a, "a", "b")
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			errs <- a.HandleLog(&log.Entry{Message: "blocked", Level: log.InfoLevel})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	err := a.Close()
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+	assert.True(t, errors.Is(err, log.ErrCloseTimeout))
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, async.ErrClosed, <-errs)
+	}
+
+	assert.Equal(t, uint64(5), a.Stats().Dropped)
+	close(g.gate)
+}
+
+func TestClose_race(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		h := memory.New()
+		a := async.New(log.HandlerFunc(func(e *log.Entry) error {
+			time.Sleep(10 * time.Microsecond)
+			return h.HandleLog(e)
+		}), async.WithQueueSize(1))
+
+		var wg sync.WaitGroup
+		var queued int64
+		for j := 0; j < 10; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if a.HandleLog(&log.Entry{Message: "upload"}) == nil {
+					atomic.AddInt64(&queued, 1)
+				}
+			}()
+		}
+
+		time.Sleep(20 * time.Microsecond)
+		assert.NoError(t, a.Close())
+		wg.Wait()
+
+		assert.Equal(t, uint64(queued), a.Stats().Handled)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	var errs []error
+
+	h := log.HandlerFunc(func(e *log.Entry) error {
+		return errors.New("boom")
+	})
+
+	a := async.New(h, async.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	a.HandleLog(&log.Entry{})
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, uint64(1), a.Stats().Failed)
+	assert.EqualError(t, errs[0], "log.HandlerFunc: boom")
+}
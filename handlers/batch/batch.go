@@ -0,0 +1,356 @@
+// Package batch implements a handler which batches entries by count, size
+// and latency, passing each batch to a function. It is useful for building
+// handlers for remote services.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// sleep for duration d, used between retries.
+var sleep = time.Sleep
+
+// pending is the number of batches which may be waiting to be sent before
+// HandleLog blocks.
+const pending = 4
+
+// ErrClosed is returned when logging to a closed handler.
+var ErrClosed = errors.New("handler closed")
+
+// Func is called with each batch of entries. Batches are passed in the
+// order they were logged, one at a time.
+type Func func(context.Context, []*log.Entry) error
+
+// permanent error.
+type permanent struct {
+	err error
+}
+
+// Error implementation.
+func (p *permanent) Error() string {
+	return p.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (p *permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent wraps `err` to indicate that it should not be retried.
+func Permanent(err error) error {
+	return &permanent{err}
+}
+
+// Handler implementation.
+type Handler struct {
+	fn           Func
+	name         string
+	maxEntries   int
+	maxBytes     int
+	maxLatency   time.Duration
+	retries      int
+	backoff      time.Duration
+	timeout      time.Duration
+	size         func(*log.Entry) int
+	errorHandler func(error)
+
+	mu      sync.Mutex
+	entries []*log.Entry
+	bytes   int
+	gen     uint64
+	timer   *time.Timer
+	closed  bool
+	batches chan []*log.Entry
+	wg      sync.WaitGroup
+
+	smu    sync.Mutex
+	cond   *sync.Cond
+	sent   uint64
+	done   uint64
+	err    error
+	errSeq uint64
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler passing batches of entries to `fn`.
+func New(fn Func, options ...Option) *Handler {
+	v := &Handler{
+		fn:           fn,
+		name:         "batch",
+		maxEntries:   100,
+		maxLatency:   time.Second,
+		retries:      3,
+		backoff:      100 * time.Millisecond,
+		timeout:      30 * time.Second,
+		size:         size,
+		errorHandler: log.HandleError,
+		batches:      make(chan []*log.Entry, pending),
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	v.cond = sync.NewCond(&v.smu)
+	v.wg.Add(1)
+	go v.loop()
+
+	return v
+}
+
+// WithName sets the handler name used in errors (default: "batch").
+func WithName(name string) Option {
+	return func(v *Handler) {
+		v.name = name
+	}
+}
+
+// WithMaxEntries sets the maximum number of entries in a batch (default: 100).
+func WithMaxEntries(n int) Option {
+	return func(v *Handler) {
+		v.maxEntries = n
+	}
+}
+
+// WithMaxBytes sets the maximum size of a batch in bytes, by default
+// the size of each entry is its JSON encoded length.
+func WithMaxBytes(n int) Option {
+	return func(v *Handler) {
+		v.maxBytes = n
+	}
+}
+
+// WithSizeFunc sets the function used to determine the size of an entry
+// for WithMaxBytes.
+func WithSizeFunc(fn func(*log.Entry) int) Option {
+	return func(v *Handler) {
+		v.size = fn
+	}
+}
+
+// WithMaxLatency sets the maximum duration an entry is buffered before
+// its batch is sent (default: 1s). Zero disables it.
+func WithMaxLatency(d time.Duration) Option {
+	return func(v *Handler) {
+		v.maxLatency = d
+	}
+}
+
+// WithRetries sets the number of times a failed batch is retried, with the
+// delay doubling from `backoff` after each attempt (default: 3, 100ms).
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(v *Handler) {
+		v.retries = n
+		v.backoff = backoff
+	}
+}
+
+// WithTimeout sets the timeout for each call to the batch function (default: 30s).
+func WithTimeout(d time.Duration) Option {
+	return func(v *Handler) {
+		v.timeout = d
+	}
+}
+
+// WithErrorHandler sets the function called with a *log.HandlerError when a
+// batch fails, by default errors are written to the stdlib log.
+func WithErrorHandler(fn func(error)) Option {
+	return func(v *Handler) {
+		v.errorHandler = fn
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrClosed
+	}
+
+	var n int
+	if h.maxBytes > 0 {
+		n = h.size(e)
+
+		if len(h.entries) > 0 && h.bytes+n > h.maxBytes {
+			h.cut()
+		}
+	}
+
+	h.entries = append(h.entries, e)
+	h.bytes += n
+
+	if len(h.entries) == 1 && h.maxLatency > 0 {
+		gen := h.gen
+		h.timer = time.AfterFunc(h.maxLatency, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.gen == gen && !h.closed {
+				h.cut()
+			}
+		})
+	}
+
+	if len(h.entries) >= h.maxEntries || h.maxBytes > 0 && h.bytes >= h.maxBytes {
+		h.cut()
+	}
+
+	return nil
+}
+
+// Flush sends the buffered entries, blocking until all batches have been
+// sent. The error of the last failed batch is returned, if any.
+func (h *Handler) Flush() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return ErrClosed
+	}
+	start, target := h.cut()
+	h.mu.Unlock()
+
+	return h.wait(start, target)
+}
+
+// Close sends the buffered entries and stops the handler, blocking until all
+// batches have been sent. The error of the last failed batch is returned, if any.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	start, target := h.cut()
+	h.closed = true
+	close(h.batches)
+	h.mu.Unlock()
+
+	err := h.wait(start, target)
+	h.wg.Wait()
+	return err
+}
+
+// cut the buffered entries into a batch. The lock must be held. It returns
+// the sequence of the last batch sent before the cut, and of the last batch
+// cut, for use with wait.
+func (h *Handler) cut() (start, target uint64) {
+	h.smu.Lock()
+	start = h.done
+	if len(h.entries) > 0 {
+		h.sent++
+	}
+	target = h.sent
+	h.smu.Unlock()
+
+	if len(h.entries) == 0 {
+		return
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+
+	h.batches <- h.entries
+	h.entries = nil
+	h.bytes = 0
+	h.gen++
+	return
+}
+
+// wait until the batch `target` has been sent, returning the error of
+// the last failed batch after `start`, if any.
+func (h *Handler) wait(start, target uint64) error {
+	h.smu.Lock()
+	defer h.smu.Unlock()
+
+	for h.done < target {
+		h.cond.Wait()
+	}
+
+	if h.errSeq > start {
+		return h.err
+	}
+
+	return nil
+}
+
+// loop sending batches.
+func (h *Handler) loop() {
+	defer h.wg.Done()
+
+	for entries := range h.batches {
+		err := h.send(entries)
+
+		if err != nil {
+			h.errorHandler(err)
+		}
+
+		h.smu.Lock()
+		h.done++
+		if err != nil {
+			h.err = err
+			h.errSeq = h.done
+		}
+		h.cond.Broadcast()
+		h.smu.Unlock()
+	}
+}
+
+// send a batch, retrying on failure.
+func (h *Handler) send(entries []*log.Entry) error {
+	backoff := h.backoff
+
+	for attempt := 0; ; attempt++ {
+		err := h.call(entries)
+		if err == nil {
+			return nil
+		}
+
+		var p *permanent
+		if errors.As(err, &p) || attempt >= h.retries {
+			if p != nil {
+				err = p.err
+			}
+
+			return &log.HandlerError{
+				Handler: h.name,
+				Entries: len(entries),
+				Err:     err,
+			}
+		}
+
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// call the batch function, returning panics as permanent errors.
+func (h *Handler) call(entries []*log.Entry) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	return h.fn(ctx, entries)
+}
+
+// size returns the JSON encoded size of the entry.
+func size(e *log.Entry) int {
+	b, _ := json.Marshal(e)
+	return len(b)
+}
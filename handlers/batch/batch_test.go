@@ -0,0 +1,169 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+)
+
+// recorder of batches.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+	calls   int
+}
+
+func (r *recorder) handle(ctx context.Context, entries []*log.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	if r.err != nil {
+		return r.err
+	}
+
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	r.batches = append(r.batches, msgs)
+
+	return nil
+}
+
+// entry returns an entry with the message `msg`.
+func entry(msg string) *log.Entry {
+	return &log.Entry{Message: msg}
+}
+
+func TestMaxEntries(t *testing.T) {
+	r := &recorder{}
+	h := New(r.handle, WithMaxEntries(2), WithMaxLatency(0))
+
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		assert.NoError(t, h.HandleLog(entry(m)))
+	}
+
+	assert.NoError(t, h.Flush())
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, r.batches)
+
+	assert.NoError(t, h.Close())
+	assert.Equal(t, ErrClosed, h.HandleLog(entry("f")))
+}
+
+func TestMaxBytes(t *testing.T) {
+	r := &recorder{}
+	h := New(r.handle,
+		WithMaxBytes(5),
+		WithMaxLatency(0),
+		WithSizeFunc(func(e *log.Entry) int {
+			return len(e.Message)
+		}))
+
+	for _, m := range []string{"aa", "bb", "cc", "ddddddd", "e"} {
+		assert.NoError(t, h.HandleLog(entry(m)))
+	}
+
+	assert.NoError(t, h.Close())
+	assert.Equal(t, [][]string{{"aa", "bb"}, {"cc"}, {"ddddddd"}, {"e"}}, r.batches)
+}
+
+func TestMaxLatency(t *testing.T) {
+	r := &recorder{}
+	h := New(r.handle, WithMaxLatency(10*time.Millisecond))
+
+	h.HandleLog(entry("a"))
+	h.HandleLog(entry("b"))
+	time.Sleep(50 * time.Millisecond)
+	h.HandleLog(entry("c"))
+
+	r.mu.Lock()
+	assert.Equal(t, [][]string{{"a", "b"}}, r.batches)
+	r.mu.Unlock()
+
+	assert.NoError(t, h.Close())
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, r.batches)
+}
+
+func TestRetries(t *testing.T) {
+	var delays []time.Duration
+	sleep = func(d time.Duration) {
+		delays = append(delays, d)
+	}
+	defer func() { sleep = time.Sleep }()
+
+	var errs []error
+	r := &recorder{err: errors.New("boom")}
+	h := New(r.handle,
+		WithName("test"),
+		WithRetries(3, time.Second),
+		WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+
+	h.HandleLog(entry("a"))
+	h.HandleLog(entry("b"))
+
+	err := h.Close()
+	assert.EqualError(t, err, "test: 2 entries lost: boom")
+	assert.Equal(t, []error{err}, errs)
+	assert.Equal(t, 4, r.calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, delays)
+}
+
+func TestPermanent(t *testing.T) {
+	r := &recorder{err: Permanent(errors.New("bad request"))}
+	h := New(r.handle, WithErrorHandler(func(error) {}))
+
+	h.HandleLog(entry("a"))
+
+	err := h.Flush()
+	assert.EqualError(t, err, "batch: bad request")
+	assert.Equal(t, 1, r.calls)
+
+	r.err = nil
+	h.HandleLog(entry("b"))
+	assert.NoError(t, h.Flush())
+	assert.NoError(t, h.Close())
+}
+
+func TestPanic(t *testing.T) {
+	var errs []error
+	h := New(func(context.Context, []*log.Entry) error {
+		panic("boom")
+	}, WithRetries(3, time.Millisecond), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	h.HandleLog(entry("a"))
+	h.HandleLog(entry("b"))
+
+	err := h.Flush()
+	assert.EqualError(t, err, "batch: 2 entries lost: panic: boom")
+
+	var e *log.HandlerError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, 2, e.Entries)
+	assert.Len(t, errs, 1)
+	assert.NoError(t, h.Close())
+}
+
+func TestFlush_failed(t *testing.T) {
+	h := New(func(context.Context, []*log.Entry) error {
+		return Permanent(errors.New("bad request"))
+	}, WithErrorHandler(func(error) {}))
+
+	for i := 0; i < 100000; i++ {
+		h.HandleLog(entry("a"))
+		assert.EqualError(t, h.Flush(), "batch: bad request")
+	}
+
+	assert.NoError(t, h.Close())
+}
@@ -73,12 +73,16 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 	color.Fprintf(h.Writer, "%s %-25s", bold.Sprintf("%*s", h.Padding+1, level), e.Message)
 
 	for _, name := range names {
-		if name == "source" {
+		if name == "source" || name == "stack" {
 			continue
 		}
 		fmt.Fprintf(h.Writer, " %s=%v", color.Sprint(name), e.Fields.Get(name))
 	}
 
+	if e.Caller != nil {
+		fmt.Fprintf(h.Writer, " %s=%s", color.Sprint("caller"), e.Caller)
+	}
+
 	fmt.Fprintln(h.Writer)
 
 	return nil
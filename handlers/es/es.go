@@ -16,7 +16,6 @@ import (
 // TODO(tj): allow dumping logs to stderr on timeout
 // TODO(tj): allow custom format that does not include .fields etc
 // TODO(tj): allow interval flushes
-// TODO(tj): allow explicit Flush() (for Lambda where you have to flush at the end of function)
 
 // Elasticsearch interface.
 type Elasticsearch interface {
@@ -25,9 +24,10 @@ type Elasticsearch interface {
 
 // Config for handler.
 type Config struct {
-	BufferSize int           // BufferSize is the number of logs to buffer before flush (default: 100)
-	Format     string        // Format for index
-	Client     Elasticsearch // Client for ES
+	BufferSize   int           // BufferSize is the number of logs to buffer before flush (default: 100)
+	Format       string        // Format for index
+	Client       Elasticsearch // Client for ES
+	ErrorHandler func(error)   // ErrorHandler is called with a *log.HandlerError when a flush fails (default: stdlib log)
 }
 
 // defaults applies defaults to the config.
@@ -39,6 +39,15 @@ func (c *Config) defaults() {
 	if c.Format == "" {
 		c.Format = "logs-06-01-02"
 	}
+
+	if c.ErrorHandler == nil {
+		c.ErrorHandler = printError
+	}
+}
+
+// printError outputs to the stdlib log.
+func printError(err error) {
+	stdlog.Printf("log/elastic: failed to flush: %s", err)
 }
 
 // Handler implementation.
@@ -47,6 +56,7 @@ type Handler struct {
 
 	mu    sync.Mutex
 	batch *batch.Batch
+	wg    sync.WaitGroup
 }
 
 // New handler with BufferSize
@@ -73,22 +83,57 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 	h.batch.Add(e)
 
 	if h.batch.Size() >= h.BufferSize {
-		go h.flush(h.batch)
+		h.wg.Add(1)
+		go func(b *batch.Batch) {
+			defer h.wg.Done()
+			if err := h.flush(b); err != nil {
+				h.ErrorHandler(err)
+			}
+		}(h.batch)
 		h.batch = nil
 	}
 
 	return nil
 }
 
-// flush the given `batch` asynchronously.
-func (h *Handler) flush(batch *batch.Batch) {
+// Flush the pending logs, and waits for any asynchronous flushes to
+// complete. This is useful in environments such as Lambda where you have
+// to flush at the end of a function.
+func (h *Handler) Flush() error {
+	defer h.wg.Wait()
+
+	h.mu.Lock()
+	b := h.batch
+	h.batch = nil
+	h.mu.Unlock()
+
+	if b == nil {
+		return nil
+	}
+
+	return h.flush(b)
+}
+
+// Close flushes the pending logs, and waits for any asynchronous
+// flushes to complete.
+func (h *Handler) Close() error {
+	return h.Flush()
+}
+
+// flush the given `batch`, returning a *log.HandlerError on failure.
+func (h *Handler) flush(batch *batch.Batch) error {
 	size := batch.Size()
 	start := time.Now()
 	stdlog.Printf("log/elastic: flushing %d logs", size)
 
 	if err := batch.Flush(); err != nil {
-		stdlog.Printf("log/elastic: failed to flush %d logs: %s", size, err)
+		return &log.HandlerError{
+			Handler: "es",
+			Entries: size,
+			Err:     err,
+		}
 	}
 
 	stdlog.Printf("log/elastic: flushed %d logs in %s", size, time.Since(start))
+	return nil
 }
@@ -0,0 +1,164 @@
+// Package failover implements a handler which passes entries to the first
+// of an ordered list of handlers that succeeds, such as a remote service
+// followed by a local file. Unlike multi, only one handler receives each entry.
+package failover
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/multi"
+)
+
+// ErrNoHandlers is returned when there are no handlers to pass entries to.
+var ErrNoHandlers = errors.New("failover: no handlers")
+
+// now returns the current time.
+var now = time.Now
+
+// defaultCooldown is used when Cooldown is zero.
+const defaultCooldown = 30 * time.Second
+
+// Handler implementation.
+//
+// When every handler fails a *multi.Error is returned, with a
+// *log.HandlerError for each failure in the order tried.
+type Handler struct {
+	Handlers []log.Handler
+
+	// Cooldown is how long a handler which failed is skipped for (default:
+	// 30s when zero). Skipped handlers are still tried when all others fail.
+	Cooldown time.Duration
+
+	// OnAccept is called with the index of the handler which accepted each entry.
+	OnAccept func(e *log.Entry, i int)
+
+	mu        sync.Mutex
+	downUntil []time.Time
+	accepted  []uint64
+}
+
+// New handler.
+func New(h ...log.Handler) *Handler {
+	return &Handler{
+		Handlers: h,
+		Cooldown: defaultCooldown,
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	if len(h.Handlers) == 0 {
+		return ErrNoHandlers
+	}
+
+	t := now()
+
+	var skipped []int
+	var errs []error
+
+	for i := range h.Handlers {
+		if h.down(i, t) {
+			skipped = append(skipped, i)
+			continue
+		}
+
+		err := h.handle(i, e, t)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, log.NewHandlerError(h.Handlers[i], err, 1))
+	}
+
+	for _, i := range skipped {
+		err := h.handle(i, e, t)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, log.NewHandlerError(h.Handlers[i], err, 1))
+	}
+
+	return &multi.Error{Errors: errs}
+}
+
+// Accepted returns the number of entries accepted by each handler.
+func (h *Handler) Accepted() []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.grow()
+	return append([]uint64(nil), h.accepted...)
+}
+
+// Flush implements log.Flusher, flushing all handlers and returning
+// the first error.
+func (h *Handler) Flush() (err error) {
+	for _, handler := range h.Handlers {
+		if e := log.FlushHandler(handler); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Close implements log.Closer, closing all handlers and returning
+// the first error.
+func (h *Handler) Close() (err error) {
+	for _, handler := range h.Handlers {
+		if e := log.CloseHandler(handler); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// handle passes the entry to handler `i`, marking it down on failure.
+func (h *Handler) handle(i int, e *log.Entry, t time.Time) error {
+	if err := h.Handlers[i].HandleLog(e); err != nil {
+		cooldown := h.Cooldown
+		if cooldown == 0 {
+			cooldown = defaultCooldown
+		}
+
+		h.mu.Lock()
+		h.grow()
+		h.downUntil[i] = t.Add(cooldown)
+		h.mu.Unlock()
+		return err
+	}
+
+	h.mu.Lock()
+	h.grow()
+	h.downUntil[i] = time.Time{}
+	h.accepted[i]++
+	h.mu.Unlock()
+
+	if h.OnAccept != nil {
+		h.OnAccept(e, i)
+	}
+
+	return nil
+}
+
+// down returns true if handler `i` is cooling down.
+func (h *Handler) down(i int, t time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.grow()
+	return t.Before(h.downUntil[i])
+}
+
+// grow sizes the handler state to match Handlers, which may be set
+// without New or appended to. The mutex must be held.
+func (h *Handler) grow() {
+	for len(h.downUntil) < len(h.Handlers) {
+		h.downUntil = append(h.downUntil, time.Time{})
+	}
+
+	for len(h.accepted) < len(h.Handlers) {
+		h.accepted = append(h.accepted, 0)
+	}
+}
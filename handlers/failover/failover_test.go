@@ -0,0 +1,104 @@
+package failover
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/multi"
+)
+
+// flaky handler failing while down.
+type flaky struct {
+	memory.Handler
+	down  bool
+	calls int
+}
+
+func (f *flaky) HandleLog(e *log.Entry) error {
+	f.calls++
+	if f.down {
+		return errors.New("unavailable")
+	}
+	return f.Handler.HandleLog(e)
+}
+
+func Test(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	now = func() time.Time {
+		return t0
+	}
+	defer func() { now = time.Now }()
+
+	a := &flaky{down: true}
+	b := &flaky{}
+	c := &flaky{}
+
+	var accepted []int
+	h := New(a, b, c)
+	h.Cooldown = time.Minute
+	h.OnAccept = func(e *log.Entry, i int) {
+		accepted = append(accepted, i)
+	}
+
+	// a fails and cools down
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "one"}))
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "two"}))
+	assert.Equal(t, 1, a.calls)
+	assert.Len(t, b.Entries, 2)
+
+	// a recovers after the cool-down
+	a.down = false
+	t0 = t0.Add(time.Minute)
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "three"}))
+	assert.Len(t, a.Entries, 1)
+
+	assert.Equal(t, []int{1, 1, 0}, accepted)
+	assert.Equal(t, []uint64{1, 2, 0}, h.Accepted())
+	assert.Equal(t, 0, c.calls)
+}
+
+func TestAllFailed(t *testing.T) {
+	a := &flaky{down: true}
+	b := &flaky{down: true}
+
+	h := New(a, b)
+
+	err := h.HandleLog(&log.Entry{})
+	assert.EqualError(t, err, "2 handlers failed: *failover.flaky: unavailable; *failover.flaky: unavailable")
+
+	var e *multi.Error
+	assert.True(t, errors.As(err, &e))
+	assert.Len(t, e.Errors, 2)
+
+	// cooling down handlers are tried as a last resort
+	a.down = false
+	assert.NoError(t, h.HandleLog(&log.Entry{}))
+	assert.Equal(t, 2, a.calls)
+	assert.Equal(t, 1, b.calls)
+	assert.Equal(t, []uint64{1, 0}, h.Accepted())
+}
+
+func TestLiteral(t *testing.T) {
+	a := &flaky{down: true}
+	b := &flaky{}
+
+	h := &Handler{Handlers: []log.Handler{a}}
+	h.Handlers = append(h.Handlers, b)
+
+	assert.NoError(t, h.HandleLog(&log.Entry{}))
+	assert.NoError(t, h.HandleLog(&log.Entry{}))
+
+	// zero Cooldown uses the default
+	assert.Equal(t, 1, a.calls)
+	assert.Equal(t, []uint64{0, 2}, h.Accepted())
+}
+
+func TestNoHandlers(t *testing.T) {
+	h := New()
+	assert.Equal(t, ErrNoHandlers, h.HandleLog(&log.Entry{}))
+}
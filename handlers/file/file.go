@@ -0,0 +1,541 @@
+// Package file implements a handler writing entries to a file, with
+// rotation by size or time, compression and retention of rotated files.
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/json"
+	"github.com/apex/log/handlers/logfmt"
+	"github.com/apex/log/handlers/text"
+)
+
+// now returns the current time.
+var now = time.Now
+
+// timeFormat is the format of timestamps in the names of rotated files.
+const timeFormat = "2006-01-02T15-04-05.000"
+
+// ErrClosed is returned when handling entries after Close.
+var ErrClosed = errors.New("file: closed")
+
+// Encoder returns a handler encoding entries to `w`.
+type Encoder func(w io.Writer) log.Handler
+
+// Encoders available.
+var (
+	JSON   Encoder = func(w io.Writer) log.Handler { return json.New(w) }
+	Logfmt Encoder = func(w io.Writer) log.Handler { return logfmt.New(w) }
+	Text   Encoder = func(w io.Writer) log.Handler { return text.New(w) }
+)
+
+// Handler implementation.
+type Handler struct {
+	path         string
+	encoder      Encoder
+	maxSize      int64
+	interval     time.Duration
+	maxAge       time.Duration
+	maxBackups   int
+	compress     bool
+	reopen       bool
+	perm         os.FileMode
+	errorHandler func(error)
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	next    time.Time
+	rotated time.Time
+	buf     bytes.Buffer
+	handler log.Handler
+	closed  bool
+
+	signals chan os.Signal
+	done    chan struct{}
+	mill    sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler writing to the file at `path`, which is created along with its
+// directory when necessary. Entries are encoded as JSON unless WithEncoder is
+// used.
+func New(path string, options ...Option) (*Handler, error) {
+	v := &Handler{
+		path:         path,
+		encoder:      JSON,
+		perm:         0644,
+		errorHandler: log.HandleError,
+		done:         make(chan struct{}),
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	v.handler = v.encoder(&v.buf)
+
+	if err := v.open(); err != nil {
+		return nil, err
+	}
+
+	if v.reopen {
+		v.signals = make(chan os.Signal, 1)
+		signal.Notify(v.signals, syscall.SIGHUP)
+		go v.handleSignals()
+	}
+
+	return v, nil
+}
+
+// WithEncoder sets the encoder, such as JSON, Logfmt or Text.
+func WithEncoder(e Encoder) Option {
+	return func(v *Handler) {
+		v.encoder = e
+	}
+}
+
+// WithMaxSize rotates the file before it exceeds `n` bytes.
+func WithMaxSize(n int64) Option {
+	return func(v *Handler) {
+		v.maxSize = n
+	}
+}
+
+// WithInterval rotates the file every interval `d`, such as 24 hours,
+// aligned to multiples of `d` since the zero time in UTC.
+func WithInterval(d time.Duration) Option {
+	return func(v *Handler) {
+		v.interval = d
+	}
+}
+
+// WithMaxAge removes rotated files older than `d`.
+func WithMaxAge(d time.Duration) Option {
+	return func(v *Handler) {
+		v.maxAge = d
+	}
+}
+
+// WithMaxBackups removes the oldest rotated files beyond `n`.
+func WithMaxBackups(n int) Option {
+	return func(v *Handler) {
+		v.maxBackups = n
+	}
+}
+
+// WithCompression gzips rotated files in the background.
+func WithCompression() Option {
+	return func(v *Handler) {
+		v.compress = true
+	}
+}
+
+// WithReopen reopens the file on SIGHUP, for use with external tools
+// which move the file, such as logrotate without copytruncate.
+func WithReopen() Option {
+	return func(v *Handler) {
+		v.reopen = true
+	}
+}
+
+// WithPerm sets the permissions of created files, defaults to 0644.
+func WithPerm(perm os.FileMode) Option {
+	return func(v *Handler) {
+		v.perm = perm
+	}
+}
+
+// WithErrorHandler sets the function called with errors compressing or
+// removing rotated files, by default errors are written to the stdlib log.
+func WithErrorHandler(fn func(error)) Option {
+	return func(v *Handler) {
+		v.errorHandler = fn
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrClosed
+	}
+
+	h.buf.Reset()
+	if err := h.handler.HandleLog(e); err != nil {
+		return err
+	}
+
+	// the file failed to open after rotating or reopening
+	if h.file == nil {
+		if err := h.open(); err != nil {
+			return err
+		}
+	}
+
+	if h.shouldRotate(int64(h.buf.Len())) {
+		if err := h.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := h.file.Write(h.buf.Bytes())
+	h.size += int64(n)
+	return err
+}
+
+// Reopen reopens the file, see WithReopen. The previous file is closed
+// only once the new one is open, so writes continue on failure.
+func (h *Handler) Reopen() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrClosed
+	}
+
+	prev := h.file
+
+	if err := h.open(); err != nil {
+		return err
+	}
+
+	if prev == nil {
+		return nil
+	}
+
+	return prev.Close()
+}
+
+// Rotate rotates the file immediately.
+func (h *Handler) Rotate() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrClosed
+	}
+
+	return h.rotate()
+}
+
+// Flush implements log.Flusher, syncing the file and waiting for
+// rotated files to be compressed and removed.
+func (h *Handler) Flush() error {
+	h.mu.Lock()
+	var err error
+	if !h.closed && h.file != nil {
+		err = h.file.Sync()
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+	return err
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+
+	h.closed = true
+	close(h.done)
+
+	if h.signals != nil {
+		signal.Stop(h.signals)
+	}
+
+	var err error
+	if h.file != nil {
+		err = h.file.Close()
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+	return err
+}
+
+// handleSignals reopens the file on SIGHUP until closed.
+func (h *Handler) handleSignals() {
+	for {
+		select {
+		case <-h.signals:
+			if err := h.Reopen(); err != nil && err != ErrClosed {
+				h.errorHandler(err)
+			}
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// open the file for appending.
+func (h *Handler) open() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, h.perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	h.file = f
+	h.size = info.Size()
+
+	if h.interval > 0 {
+		h.next = now().UTC().Truncate(h.interval).Add(h.interval)
+	}
+
+	return nil
+}
+
+// shouldRotate returns true if the file should be rotated before writing `n` bytes.
+func (h *Handler) shouldRotate(n int64) bool {
+	if h.maxSize > 0 && h.size > 0 && h.size+n > h.maxSize {
+		return true
+	}
+
+	return h.interval > 0 && !now().Before(h.next)
+}
+
+// rotate renames the file and opens a new one, compressing and removing
+// rotated files in the background. On failure the file is reopened at its
+// original path.
+func (h *Handler) rotate() error {
+	if h.file != nil {
+		err := h.file.Close()
+		h.file = nil
+		if err != nil {
+			return h.restore(err)
+		}
+	}
+
+	name := h.backupName()
+
+	if err := os.Rename(h.path, name); err != nil && !os.IsNotExist(err) {
+		return h.restore(err)
+	}
+
+	if err := h.open(); err != nil {
+		os.Rename(name, h.path)
+		return h.restore(err)
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.millRun()
+	}()
+
+	return nil
+}
+
+// restore reopens the file after a failed rotation, returning `err`. When
+// this fails too the file is opened again by the next entry.
+func (h *Handler) restore(err error) error {
+	h.open()
+	return err
+}
+
+// backupName returns the name for the file rotated now, such as
+// "app-2006-01-02T15-04-05.000.log" for "app.log". The time is advanced
+// past the previously rotated file, and any existing rotated files, so
+// that names are unique and ordered.
+func (h *Handler) backupName() string {
+	prefix, ext := h.nameParts()
+
+	t := now().UTC().Truncate(time.Millisecond)
+	if !t.After(h.rotated) {
+		t = h.rotated.Add(time.Millisecond)
+	}
+
+	for ; ; t = t.Add(time.Millisecond) {
+		name := prefix + t.Format(timeFormat) + ext
+		if !exists(name) && !exists(name+".gz") {
+			h.rotated = t
+			return name
+		}
+	}
+}
+
+// exists returns true if a file exists at `path`.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// nameParts returns the prefix and extension of rotated file names.
+func (h *Handler) nameParts() (prefix, ext string) {
+	ext = filepath.Ext(h.path)
+	return strings.TrimSuffix(h.path, ext) + "-", ext
+}
+
+// backup is a rotated file.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files, newest first.
+func (h *Handler) backups() ([]backup, error) {
+	prefix, ext := h.nameParts()
+
+	files, err := ioutil.ReadDir(filepath.Dir(h.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var v []backup
+	for _, f := range files {
+		path := filepath.Join(filepath.Dir(h.path), f.Name())
+
+		if f.IsDir() || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		s := strings.TrimPrefix(path, prefix)
+		s = strings.TrimSuffix(s, ".gz")
+
+		if !strings.HasSuffix(s, ext) {
+			continue
+		}
+
+		t, err := time.Parse(timeFormat, strings.TrimSuffix(s, ext))
+		if err != nil {
+			continue
+		}
+
+		v = append(v, backup{path: path, time: t})
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].time.After(v[j].time)
+	})
+
+	return v, nil
+}
+
+// millRun compresses and removes rotated files.
+func (h *Handler) millRun() {
+	h.mill.Lock()
+	defer h.mill.Unlock()
+
+	if err := h.millRunOnce(); err != nil {
+		h.errorHandler(&log.HandlerError{Handler: "file", Err: err})
+	}
+}
+
+// millRunOnce compresses and removes rotated files, returning the first error.
+func (h *Handler) millRunOnce() error {
+	files, err := h.backups()
+	if err != nil {
+		return err
+	}
+
+	var keep []backup
+
+	for i, b := range files {
+		expired := h.maxAge > 0 && now().Sub(b.time) > h.maxAge
+		excess := h.maxBackups > 0 && i >= h.maxBackups
+
+		if expired || excess {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		keep = append(keep, b)
+	}
+
+	if !h.compress {
+		return nil
+	}
+
+	for _, b := range keep {
+		if strings.HasSuffix(b.path, ".gz") {
+			continue
+		}
+
+		if err := compress(b.path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compress gzips the file at `path` to `path`.gz, removing the original.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,256 @@
+package file
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+)
+
+// clock sets now to a fixed time, which may be changed, until reset.
+func clock() (*time.Time, func()) {
+	t := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time {
+		return t
+	}
+	return &t, func() { now = time.Now }
+}
+
+// tempDir returns a temporary directory, and a function removing it.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "file")
+	assert.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func entry(msg string) *log.Entry {
+	return &log.Entry{
+		Level:     log.InfoLevel,
+		Message:   msg,
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Fields:    log.Fields{"user": "tobi"},
+	}
+}
+
+func read(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func list(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func Test(t *testing.T) {
+	dir, remove := tempDir(t)
+	defer remove()
+
+	path := filepath.Join(dir, "logs", "app.log")
+
+	h, err := New(path, WithEncoder(Logfmt))
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.HandleLog(entry("hello")))
+	assert.NoError(t, h.HandleLog(entry("world")))
+	assert.NoError(t, h.Flush())
+	assert.NoError(t, h.Close())
+
+	assert.Equal(t, "timestamp=2020-01-02T03:04:05Z level=info message=hello user=tobi\ntimestamp=2020-01-02T03:04:05Z level=info message=world user=tobi\n", read(t, path))
+	assert.Equal(t, ErrClosed, h.HandleLog(entry("closed")))
+	assert.NoError(t, h.Close())
+}
+
+func TestHandler_maxSize(t *testing.T) {
+	_, reset := clock()
+	defer reset()
+
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithMaxSize(150))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	for _, msg := range []string{"one", "two", "three"} {
+		assert.NoError(t, h.HandleLog(entry(msg)))
+	}
+
+	assert.NoError(t, h.Flush())
+
+	assert.Equal(t, []string{
+		"app-2020-01-02T03-04-05.000.log",
+		"app-2020-01-02T03-04-05.001.log",
+		"app.log",
+	}, list(t, dir))
+
+	assert.Contains(t, read(t, filepath.Join(dir, "app-2020-01-02T03-04-05.000.log")), `"message":"one"`)
+	assert.Contains(t, read(t, path), `"message":"three"`)
+}
+
+func TestHandler_interval(t *testing.T) {
+	v, reset := clock()
+	defer reset()
+
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithInterval(time.Hour))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(entry("one")))
+	assert.NoError(t, h.HandleLog(entry("two")))
+
+	*v = v.Add(time.Hour)
+	assert.NoError(t, h.HandleLog(entry("three")))
+	assert.NoError(t, h.Flush())
+
+	assert.Equal(t, []string{"app-2020-01-02T04-04-05.000.log", "app.log"}, list(t, dir))
+	assert.Contains(t, read(t, path), `"message":"three"`)
+}
+
+func TestHandler_retention(t *testing.T) {
+	_, reset := clock()
+	defer reset()
+
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithCompression(), WithMaxBackups(2), WithErrorHandler(func(err error) {
+		t.Error(err)
+	}))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		assert.NoError(t, h.HandleLog(entry(msg)))
+		assert.NoError(t, h.Rotate())
+	}
+
+	assert.NoError(t, h.Flush())
+
+	assert.Equal(t, []string{
+		"app-2020-01-02T03-04-05.002.log.gz",
+		"app-2020-01-02T03-04-05.003.log.gz",
+		"app.log",
+	}, list(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "app-2020-01-02T03-04-05.003.log.gz"))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"message":"four"`)
+}
+
+func TestHandler_maxAge(t *testing.T) {
+	v, reset := clock()
+	defer reset()
+
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithMaxAge(time.Hour))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(entry("one")))
+	assert.NoError(t, h.Rotate())
+	assert.NoError(t, h.Flush())
+
+	*v = v.Add(2 * time.Hour)
+	assert.NoError(t, h.HandleLog(entry("two")))
+	assert.NoError(t, h.Rotate())
+	assert.NoError(t, h.Flush())
+
+	assert.Equal(t, []string{"app-2020-01-02T05-04-05.000.log", "app.log"}, list(t, dir))
+}
+
+func TestHandler_Reopen(t *testing.T) {
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithEncoder(Text), WithReopen())
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(entry("one")))
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, h.HandleLog(entry("two")))
+	assert.NoError(t, h.Reopen())
+	assert.NoError(t, h.HandleLog(entry("three")))
+
+	assert.Contains(t, read(t, path+".1"), "two")
+	assert.Contains(t, read(t, path), "three")
+	assert.NotContains(t, read(t, path), "two")
+}
+
+func TestHandler_Reopen_error(t *testing.T) {
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithEncoder(Text))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	// a directory at the path fails to open
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.Mkdir(path, 0755))
+	assert.Error(t, h.Reopen())
+
+	// the previous file is still written
+	assert.NoError(t, h.HandleLog(entry("one")))
+	assert.Contains(t, read(t, path+".1"), "one")
+}
+
+func TestHandler_rotate_error(t *testing.T) {
+	dir, remove := tempDir(t)
+	defer remove()
+	path := filepath.Join(dir, "app.log")
+
+	h, err := New(path, WithEncoder(Text))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(entry("one")))
+
+	// a file in place of the directory fails the rename and reopen
+	h.path = filepath.Join(dir, "file", "app.log")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+	assert.Error(t, h.Rotate())
+
+	// the file is opened again by the next entry
+	h.path = path
+	assert.NoError(t, h.HandleLog(entry("two")))
+	assert.NoError(t, h.Flush())
+
+	assert.Contains(t, read(t, path), "one")
+	assert.Contains(t, read(t, path), "two")
+}
@@ -0,0 +1,206 @@
+// Package filter implements a handler which passes entries matching a
+// predicate to another handler. Predicates may be composed in Go, or
+// parsed from expressions such as:
+//
+//	level >= warn and fields.user == "tobi" and message ~ "upload"
+//
+// See Parse for the expression syntax.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Predicate returns true for matching entries.
+type Predicate func(*log.Entry) bool
+
+// Handler implementation.
+type Handler struct {
+	Handler   log.Handler
+	Predicate Predicate
+}
+
+// New handler passing entries matching `p` to `h`. Use Not to drop
+// matching entries instead.
+func New(h log.Handler, p Predicate) *Handler {
+	return &Handler{
+		Handler:   h,
+		Predicate: p,
+	}
+}
+
+// NewExpr handler passing entries matching the expression `expr` to `h`.
+func NewExpr(h log.Handler, expr string) (*Handler, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(h, p), nil
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	if !h.Predicate(e) {
+		return nil
+	}
+
+	return h.Handler.HandleLog(e)
+}
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	return log.FlushHandler(h.Handler)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	return log.CloseHandler(h.Handler)
+}
+
+// And returns a predicate matching when all of `p` match.
+func And(p ...Predicate) Predicate {
+	return func(e *log.Entry) bool {
+		for _, fn := range p {
+			if !fn(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate matching when any of `p` match.
+func Or(p ...Predicate) Predicate {
+	return func(e *log.Entry) bool {
+		for _, fn := range p {
+			if fn(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a predicate matching when `p` does not.
+func Not(p Predicate) Predicate {
+	return func(e *log.Entry) bool {
+		return !p(e)
+	}
+}
+
+// Level returns a predicate matching levels from `min` to `max` inclusive.
+func Level(min, max log.Level) Predicate {
+	return func(e *log.Entry) bool {
+		return e.Level >= min && e.Level <= max
+	}
+}
+
+// MinLevel returns a predicate matching `level` and above.
+func MinLevel(level log.Level) Predicate {
+	return Level(level, log.FatalLevel)
+}
+
+// Message returns a predicate matching messages against `re`.
+func Message(re *regexp.Regexp) Predicate {
+	return func(e *log.Entry) bool {
+		return re.MatchString(e.Message)
+	}
+}
+
+// HasField returns a predicate matching entries with the field `name`.
+// Dots in `name` refer to nested fields, such as "request.id".
+func HasField(name string) Predicate {
+	return func(e *log.Entry) bool {
+		_, ok := lookup(e.Fields, name)
+		return ok
+	}
+}
+
+// FieldEquals returns a predicate matching entries with the field `name`
+// equal to `value`. Numbers are compared by value regardless of their type.
+func FieldEquals(name string, value interface{}) Predicate {
+	return func(e *log.Entry) bool {
+		v, ok := lookup(e.Fields, name)
+		return ok && equal(v, value)
+	}
+}
+
+// Logger returns a predicate matching entries from the named logger `name`
+// and its descendants, see log.Logger.Named.
+func Logger(name string) Predicate {
+	return func(e *log.Entry) bool {
+		v, _ := e.Fields["logger"].(string)
+		return v == name || strings.HasPrefix(v, name+".")
+	}
+}
+
+// lookup returns the field `name`, with dots referring to nested fields
+// when there is no field with the literal name.
+func lookup(fields log.Fields, name string) (interface{}, bool) {
+	if v, ok := fields[name]; ok {
+		return v, true
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+
+	switch v := fields[parts[0]].(type) {
+	case log.Fields:
+		return lookup(v, parts[1])
+	case map[string]interface{}:
+		return lookup(log.Fields(v), parts[1])
+	default:
+		return nil, false
+	}
+}
+
+// equal returns true if `a` and `b` are equal, comparing numbers by value.
+func equal(a, b interface{}) bool {
+	x, ok1 := number(a)
+	y, ok2 := number(b)
+
+	if ok1 && ok2 {
+		return x == y
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// number returns `v` as a float64 if it is numeric.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,163 @@
+package filter_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/filter"
+	"github.com/apex/log/handlers/memory"
+)
+
+func Test(t *testing.T) {
+	h := memory.New()
+
+	ctx := log.Logger{
+		Handler: filter.New(h, filter.MustParse(`level >= warn or fields.user == "tobi"`)),
+		Level:   log.DebugLevel,
+	}
+
+	ctx.Info("hello")
+	ctx.WithField("user", "tobi").Info("upload")
+	ctx.Warn("disk")
+
+	assert.Len(t, h.Entries, 2)
+	assert.Equal(t, "upload", h.Entries[0].Message)
+	assert.Equal(t, "disk", h.Entries[1].Message)
+}
+
+func TestNewExpr(t *testing.T) {
+	_, err := filter.NewExpr(memory.New(), `level >=`)
+	assert.EqualError(t, err, `filter: unexpected end of expression at offset 8`)
+}
+
+func TestPredicates(t *testing.T) {
+	e := &log.Entry{
+		Level:   log.WarnLevel,
+		Message: "upload failed",
+		Fields: log.Fields{
+			"user":    "tobi",
+			"size":    1024,
+			"logger":  "http.server",
+			"request": log.Fields{"id": "abc"},
+		},
+	}
+
+	assert.True(t, filter.Level(log.InfoLevel, log.WarnLevel)(e))
+	assert.False(t, filter.MinLevel(log.ErrorLevel)(e))
+	assert.True(t, filter.Message(regexp.MustCompile(`^upload`))(e))
+	assert.True(t, filter.HasField("request.id")(e))
+	assert.False(t, filter.HasField("request.method")(e))
+	assert.True(t, filter.FieldEquals("size", 1024.0)(e))
+	assert.False(t, filter.FieldEquals("user", "loki")(e))
+	assert.True(t, filter.Logger("http")(e))
+	assert.True(t, filter.Logger("http.server")(e))
+	assert.False(t, filter.Logger("htt")(e))
+	assert.True(t, filter.And(filter.HasField("user"), filter.Not(filter.HasField("error")))(e))
+	assert.False(t, filter.Or(filter.HasField("error"), filter.MinLevel(log.ErrorLevel))(e))
+}
+
+func TestParse(t *testing.T) {
+	e := &log.Entry{
+		Level:   log.WarnLevel,
+		Message: "upload failed",
+		Fields: log.Fields{
+			"user":    "tobi",
+			"size":    1024,
+			"ok":      false,
+			"logger":  "http.server",
+			"request": map[string]interface{}{"id": "abc"},
+			"quote":   "it's",
+			"mixed":   `a"b`,
+		},
+	}
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{`level >= warn and fields.user == "tobi" and message ~ "upload"`, true},
+		{`level > warn`, false},
+		{`level == "warning"`, true},
+		{`level != warn`, false},
+		{`level < error && level >= INFO`, true},
+		{`fields.user == 'tobi'`, true},
+		{`fields.quote == 'it\'s'`, true},
+		{`fields.quote == "it's"`, true},
+		{`fields.mixed == 'a\"b'`, true},
+		{`fields.mixed == 'a"b'`, true},
+		{`fields.mixed == "a\"b"`, true},
+		{`fields.user != "tobi"`, false},
+		{`fields.missing != "tobi"`, true},
+		{`fields.missing == "tobi"`, false},
+		{`fields.size > 1000`, true},
+		{`fields.size <= 1000`, false},
+		{`fields.size == 1024`, true},
+		{`fields.size >= -1.5`, true},
+		{`fields.user < "zed"`, true},
+		{`fields.user > 5`, false},
+		{`fields.ok == false`, true},
+		{`fields.request.id == abc`, true},
+		{`fields.user`, true},
+		{`fields.missing`, false},
+		{`not fields.missing`, true},
+		{`!fields.user`, false},
+		{`message ~ "fail(ed)?$"`, true},
+		{`message !~ "^upload"`, false},
+		{`fields.missing !~ "x"`, true},
+		{`logger ~ "^http"`, true},
+		{`logger == "http.server"`, true},
+		{`message`, true},
+		{`level >= error or (fields.user == "tobi" and not fields.size < 100)`, true},
+		{`level >= error OR fields.user == "loki" || message ~ "upload"`, true},
+		{`(level >= error or fields.user == "loki") and message ~ "upload"`, false},
+		{`not (level >= error)`, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			fn, err := filter.Parse(c.expr)
+			assert.NoError(t, err)
+			if err == nil {
+				assert.Equal(t, c.match, fn(e))
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{``, `filter: unexpected end of expression at offset 0`},
+		{`level >= loud`, `filter: invalid level "loud" at offset 9`},
+		{`level ~ "warn"`, `filter: operator "~" is not supported for level at offset 8`},
+		{`timestamp > 5`, `filter: unknown reference "timestamp" at offset 0`},
+		{`fields.`, `filter: unknown reference "fields." at offset 0`},
+		{`message ~ "("`, "filter: invalid regexp \"(\" at offset 10: error parsing regexp: missing closing ): `(`"},
+		{`message == "foo`, `filter: unterminated string at offset 11`},
+		{`(message`, `filter: unexpected end of expression at offset 8`},
+		{`message)`, `filter: unexpected ")" at offset 7`},
+		{`message and`, `filter: unexpected end of expression at offset 11`},
+		{`message == and`, `filter: unexpected "and" at offset 11`},
+		{`fields.size == -`, `filter: invalid number "-" at offset 15`},
+		{`message # x`, `filter: unexpected character '#' at offset 8`},
+		{`== "foo"`, `filter: unexpected "==" at offset 0`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			_, err := filter.Parse(c.expr)
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	assert.Panics(t, func() {
+		filter.MustParse(`level >=`)
+	})
+}
@@ -0,0 +1,541 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Parse returns a predicate for the expression `expr`.
+//
+// Expressions compare a reference with a literal, for example
+// `level >= warn` or `fields.user == "tobi"`, and may be combined
+// with "and", "or" and "not" (or "&&", "||" and "!") and grouped with
+// parentheses. The references are:
+//
+//   - level: the entry level, compared with a level name
+//   - message: the entry message
+//   - logger: the named logger, see log.Logger.Named
+//   - fields.<name>: a field, with further dots referring to nested fields
+//
+// The operators are ==, !=, <, <=, >, >=, ~ (matches regexp) and !~ (does
+// not match regexp). Numbers are compared numerically, anything else by its
+// string representation. A reference on its own matches when present, that
+// is when a field is set, or the message or logger is non-empty.
+func Parse(expr string) (Predicate, error) {
+	p := &parser{lexer: lexer{input: expr}}
+	p.next()
+
+	fn, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+
+	return fn, nil
+}
+
+// MustParse is like Parse but panics on error.
+func MustParse(expr string) Predicate {
+	fn, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return fn
+}
+
+// tokenKind is a kind of token.
+type tokenKind int
+
+// Token kinds.
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+// token is a lexed token.
+type token struct {
+	kind   tokenKind
+	value  string
+	offset int
+}
+
+// String implementation.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits an expression into tokens.
+type lexer struct {
+	input  string
+	offset int
+}
+
+// operators in order of precedence when lexing.
+var operators = []string{"==", "!=", "<=", ">=", "!~", "&&", "||", "<", ">", "~", "!"}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	for l.offset < len(l.input) && isSpace(l.input[l.offset]) {
+		l.offset++
+	}
+
+	start := l.offset
+	if start >= len(l.input) {
+		return token{kind: tokenEOF, offset: start}, nil
+	}
+
+	c := l.input[start]
+	rest := l.input[start:]
+
+	switch {
+	case c == '(':
+		l.offset++
+		return token{kind: tokenLeftParen, value: "(", offset: start}, nil
+	case c == ')':
+		l.offset++
+		return token{kind: tokenRightParen, value: ")", offset: start}, nil
+	case c == '"' || c == '\'':
+		return l.quoted(c)
+	case c == '-' || isDigit(c):
+		l.offset++
+		for l.offset < len(l.input) && (isDigit(l.input[l.offset]) || l.input[l.offset] == '.') {
+			l.offset++
+		}
+		return token{kind: tokenNumber, value: l.input[start:l.offset], offset: start}, nil
+	case isIdentStart(c):
+		for l.offset < len(l.input) && isIdent(l.input[l.offset]) {
+			l.offset++
+		}
+		return token{kind: tokenIdent, value: l.input[start:l.offset], offset: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.offset += len(op)
+			return token{kind: tokenOperator, value: op, offset: start}, nil
+		}
+	}
+
+	return token{}, fmt.Errorf("filter: unexpected character %q at offset %d", c, start)
+}
+
+// quoted lexes a string quoted with `q`.
+func (l *lexer) quoted(q byte) (token, error) {
+	start := l.offset
+	l.offset++
+
+	for l.offset < len(l.input) {
+		switch l.input[l.offset] {
+		case '\\':
+			l.offset += 2
+			continue
+		case q:
+			l.offset++
+			v, err := unquote(l.input[start+1:l.offset-1], q)
+			if err != nil {
+				return token{}, fmt.Errorf("filter: invalid string at offset %d", start)
+			}
+
+			return token{kind: tokenString, value: v, offset: start}, nil
+		}
+		l.offset++
+	}
+
+	return token{}, fmt.Errorf("filter: unterminated string at offset %d", start)
+}
+
+// unquote returns the body of a string quoted with `q` unescaped. Both
+// quotes may be escaped regardless of `q`.
+func unquote(s string, q byte) (string, error) {
+	var b strings.Builder
+
+	for len(s) > 0 {
+		if len(s) > 1 && s[0] == '\\' && (s[1] == '\'' || s[1] == '"') {
+			b.WriteByte(s[1])
+			s = s[2:]
+			continue
+		}
+
+		r, multibyte, tail, err := strconv.UnquoteChar(s, q)
+		if err != nil {
+			return "", err
+		}
+
+		if r < 0x80 && !multibyte {
+			b.WriteByte(byte(r))
+		} else {
+			b.WriteRune(r)
+		}
+
+		s = tail
+	}
+
+	return b.String(), nil
+}
+
+// parser is a recursive descent parser producing predicates.
+type parser struct {
+	lexer lexer
+	tok   token
+	err   error
+}
+
+// next advances to the next token.
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lexer.next()
+}
+
+// unexpected returns an error for the current token.
+func (p *parser) unexpected() error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("filter: unexpected %s at offset %d", p.tok, p.tok.offset)
+}
+
+// keyword returns true if the current token is any of `names`.
+func (p *parser) keyword(names ...string) bool {
+	for _, name := range names {
+		switch p.tok.kind {
+		case tokenIdent:
+			if strings.EqualFold(p.tok.value, name) {
+				return true
+			}
+		case tokenOperator:
+			if p.tok.value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseOr parses `and { "or" and }`.
+func (p *parser) parseOr() (Predicate, error) {
+	var list []Predicate
+
+	for {
+		fn, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, fn)
+
+		if !p.keyword("or", "||") {
+			break
+		}
+		p.next()
+	}
+
+	if len(list) == 1 {
+		return list[0], nil
+	}
+
+	return Or(list...), nil
+}
+
+// parseAnd parses `unary { "and" unary }`.
+func (p *parser) parseAnd() (Predicate, error) {
+	var list []Predicate
+
+	for {
+		fn, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, fn)
+
+		if !p.keyword("and", "&&") {
+			break
+		}
+		p.next()
+	}
+
+	if len(list) == 1 {
+		return list[0], nil
+	}
+
+	return And(list...), nil
+}
+
+// parseUnary parses `"not" unary | "(" or ")" | comparison`.
+func (p *parser) parseUnary() (Predicate, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if p.keyword("not", "!") {
+		p.next()
+		fn, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(fn), nil
+	}
+
+	if p.tok.kind == tokenLeftParen {
+		p.next()
+		fn, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRightParen {
+			return nil, p.unexpected()
+		}
+		p.next()
+		return fn, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses `reference [ operator literal ]`.
+func (p *parser) parseComparison() (Predicate, error) {
+	if p.tok.kind != tokenIdent || isKeyword(p.tok.value) {
+		return nil, p.unexpected()
+	}
+
+	ref := p.tok
+	get, err := reference(ref)
+	if err != nil {
+		return nil, err
+	}
+	p.next()
+
+	if p.tok.kind != tokenOperator || !isComparison(p.tok.value) {
+		if p.err != nil {
+			return nil, p.err
+		}
+		return exists(get), nil
+	}
+
+	op := p.tok.value
+	p.next()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	lit := p.tok
+	switch {
+	case lit.kind == tokenIdent && !isKeyword(lit.value):
+	case lit.kind == tokenString, lit.kind == tokenNumber:
+	default:
+		return nil, p.unexpected()
+	}
+	p.next()
+
+	if ref.value == "level" {
+		return compareLevel(op, lit)
+	}
+
+	return compare(get, op, lit)
+}
+
+// getter returns the value referenced in an entry, if present.
+type getter func(*log.Entry) (interface{}, bool)
+
+// reference returns a getter for the reference `t`.
+func reference(t token) (getter, error) {
+	switch name := t.value; {
+	case name == "level":
+		return func(e *log.Entry) (interface{}, bool) {
+			return e.Level, true
+		}, nil
+	case name == "message":
+		return func(e *log.Entry) (interface{}, bool) {
+			return e.Message, e.Message != ""
+		}, nil
+	case name == "logger":
+		return func(e *log.Entry) (interface{}, bool) {
+			v, ok := e.Fields["logger"].(string)
+			return v, ok && v != ""
+		}, nil
+	case strings.HasPrefix(name, "fields.") && len(name) > len("fields."):
+		name = strings.TrimPrefix(name, "fields.")
+		return func(e *log.Entry) (interface{}, bool) {
+			return lookup(e.Fields, name)
+		}, nil
+	default:
+		return nil, fmt.Errorf("filter: unknown reference %q at offset %d", name, t.offset)
+	}
+}
+
+// exists returns a predicate matching when `get` finds a value.
+func exists(get getter) Predicate {
+	return func(e *log.Entry) bool {
+		_, ok := get(e)
+		return ok
+	}
+}
+
+// compareLevel returns a predicate comparing the entry level.
+func compareLevel(op string, lit token) (Predicate, error) {
+	level, err := log.ParseLevel(lit.value)
+	if err != nil {
+		return nil, fmt.Errorf("filter: invalid level %s at offset %d", lit, lit.offset)
+	}
+
+	var cmp func(a, b log.Level) bool
+
+	switch op {
+	case "==":
+		cmp = func(a, b log.Level) bool { return a == b }
+	case "!=":
+		cmp = func(a, b log.Level) bool { return a != b }
+	case "<":
+		cmp = func(a, b log.Level) bool { return a < b }
+	case "<=":
+		cmp = func(a, b log.Level) bool { return a <= b }
+	case ">":
+		cmp = func(a, b log.Level) bool { return a > b }
+	case ">=":
+		cmp = func(a, b log.Level) bool { return a >= b }
+	default:
+		return nil, fmt.Errorf("filter: operator %q is not supported for level at offset %d", op, lit.offset)
+	}
+
+	return func(e *log.Entry) bool {
+		return cmp(e.Level, level)
+	}, nil
+}
+
+// compare returns a predicate comparing the value from `get` with `lit`.
+// Missing values only match != and !~.
+func compare(get getter, op string, lit token) (Predicate, error) {
+	if op == "~" || op == "!~" {
+		re, err := regexp.Compile(lit.value)
+		if err != nil {
+			return nil, fmt.Errorf("filter: invalid regexp %s at offset %d: %s", lit, lit.offset, err)
+		}
+
+		negate := op == "!~"
+		return func(e *log.Entry) bool {
+			v, ok := get(e)
+			if !ok {
+				return negate
+			}
+			return re.MatchString(fmt.Sprint(v)) != negate
+		}, nil
+	}
+
+	var want interface{} = lit.value
+	if lit.kind == tokenNumber {
+		n, err := strconv.ParseFloat(lit.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filter: invalid number %s at offset %d", lit, lit.offset)
+		}
+		want = n
+	}
+
+	return func(e *log.Entry) bool {
+		v, ok := get(e)
+		if !ok {
+			return op == "!="
+		}
+
+		switch op {
+		case "==":
+			return equal(v, want)
+		case "!=":
+			return !equal(v, want)
+		}
+
+		c, ok := order(v, want)
+		if !ok {
+			return false
+		}
+
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}, nil
+}
+
+// order compares `a` with `b`, numerically when both are numbers and by
+// their string representation otherwise, unless only one is a number.
+func order(a, b interface{}) (int, bool) {
+	x, ok1 := number(a)
+	y, ok2 := number(b)
+
+	switch {
+	case ok1 && ok2:
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case ok2:
+		return 0, false
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)), true
+	}
+}
+
+// isComparison returns true if `op` is a comparison operator.
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "~", "!~":
+		return true
+	default:
+		return false
+	}
+}
+
+// isKeyword returns true if `s` is a reserved word.
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not":
+		return true
+	default:
+		return false
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.' || c == '-'
+}
@@ -1,61 +1,428 @@
-// Package implements a Graylog-backed handler.
+// Package graylog implements a Graylog handler using GELF 1.1 over UDP,
+// TCP or TLS.
 package graylog
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/apex/log"
-	"github.com/aphistic/golf"
+	"github.com/apex/log/internal/safe"
+)
+
+// Compression of UDP messages.
+type Compression int
+
+// Compression methods available.
+const (
+	Gzip Compression = iota
+	Zlib
+	None
 )
 
+// DefaultChunkSize is the default maximum size of UDP datagrams.
+const DefaultChunkSize = 1420
+
+// maxChunks is the maximum number of chunks of a message.
+const maxChunks = 128
+
+// chunkHeaderSize is the size of the chunk header.
+const chunkHeaderSize = 12
+
+// ErrTooLarge is returned for UDP messages exceeding the maximum number of chunks.
+var ErrTooLarge = errors.New("graylog: message too large")
+
+// DefaultLevels maps levels to syslog severities.
+var DefaultLevels = map[log.Level]int{
+	log.DebugLevel: 7,
+	log.InfoLevel:  6,
+	log.WarnLevel:  4,
+	log.ErrorLevel: 3,
+	log.FatalLevel: 2,
+}
+
 // Handler implementation.
 type Handler struct {
-	logger *golf.Logger
-	client *golf.Client
+	network     string
+	address     string
+	tlsConfig   *tls.Config
+	host        string
+	facility    string
+	compression Compression
+	chunkSize   int
+	levels      map[log.Level]int
+	fields      log.Fields
+	timeout     time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
 }
 
+// Option function.
+type Option func(*Handler)
+
 // New handler.
-// Connection string should be in format "udp://<ip_address>:<port>".
+// Connection string should be in format "udp://<ip_address>:<port>",
+// "tcp://<ip_address>:<port>" or "tls://<ip_address>:<port>".
 // Server should have GELF input enabled on that port.
-func New(url string) (*Handler, error) {
-	c, err := golf.NewClient()
+func New(rawurl string, options ...Option) (*Handler, error) {
+	u, err := url.Parse(rawurl)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.Dial(url)
-	if err != nil {
-		return nil, err
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("graylog: unsupported scheme %q", u.Scheme)
 	}
 
-	l, err := c.NewLogger()
-	if err != nil {
+	host, _ := os.Hostname()
+
+	v := &Handler{
+		network:   u.Scheme,
+		address:   u.Host,
+		host:      host,
+		chunkSize: DefaultChunkSize,
+		levels:    DefaultLevels,
+		timeout:   5 * time.Second,
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	if v.chunkSize <= chunkHeaderSize {
+		return nil, fmt.Errorf("graylog: chunk size %d too small", v.chunkSize)
+	}
+
+	if err := v.connect(); err != nil {
 		return nil, err
 	}
 
-	return &Handler{
-		logger: l,
-		client: c,
-	}, nil
+	return v, nil
+}
+
+// WithTLSConfig sets the TLS config used for "tls://" urls.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(v *Handler) {
+		v.tlsConfig = c
+	}
+}
+
+// WithHost sets the host, defaults to os.Hostname().
+func WithHost(s string) Option {
+	return func(v *Handler) {
+		v.host = s
+	}
+}
+
+// WithFacility sets the "_facility" field.
+func WithFacility(s string) Option {
+	return func(v *Handler) {
+		v.facility = s
+	}
+}
+
+// WithCompression sets the compression of UDP messages, defaults to Gzip.
+// TCP messages are never compressed.
+func WithCompression(c Compression) Option {
+	return func(v *Handler) {
+		v.compression = c
+	}
+}
+
+// WithChunkSize sets the maximum size of UDP datagrams, larger messages
+// are chunked. Defaults to DefaultChunkSize.
+func WithChunkSize(n int) Option {
+	return func(v *Handler) {
+		v.chunkSize = n
+	}
+}
+
+// WithLevels sets the mapping of levels to syslog severities, defaults
+// to DefaultLevels.
+func WithLevels(levels map[log.Level]int) Option {
+	return func(v *Handler) {
+		v.levels = levels
+	}
+}
+
+// WithFields sets additional fields added to every message.
+func WithFields(fields log.Fields) Option {
+	return func(v *Handler) {
+		v.fields = fields
+	}
+}
+
+// WithTimeout sets the timeout for connecting and writing, defaults to 5 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(v *Handler) {
+		v.timeout = d
+	}
 }
 
 // HandleLog implements log.Handler.
 func (h *Handler) HandleLog(e *log.Entry) error {
-	switch e.Level {
-	case log.DebugLevel:
-		return h.logger.Dbgm(e.Fields, e.Message)
-	case log.InfoLevel:
-		return h.logger.Infom(e.Fields, e.Message)
-	case log.WarnLevel:
-		return h.logger.Warnm(e.Fields, e.Message)
-	case log.ErrorLevel:
-		return h.logger.Errm(e.Fields, e.Message)
-	case log.FatalLevel:
-		return h.logger.Critm(e.Fields, e.Message)
+	b, err := json.Marshal(h.message(e))
+	if err != nil {
+		return err
 	}
 
-	return nil
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.network == "udp" {
+		return h.writeUDP(b)
+	}
+
+	b = append(b, 0)
+
+	err = h.write(b)
+	if err == nil {
+		return nil
+	}
+
+	// reconnect and retry once
+	h.close()
+	return h.write(b)
 }
 
-// Closes connection to server, flushing message queue.
+// Close closes the connection to the server.
 func (h *Handler) Close() error {
-	return h.client.Close()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.close()
+}
+
+// close the connection, if any.
+func (h *Handler) close() error {
+	if h.conn == nil {
+		return nil
+	}
+
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+// connect to the server.
+func (h *Handler) connect() error {
+	var conn net.Conn
+	var err error
+
+	switch h.network {
+	case "tls":
+		d := &net.Dialer{Timeout: h.timeout}
+		conn, err = tls.DialWithDialer(d, "tcp", h.address, h.tlsConfig)
+	default:
+		conn, err = net.DialTimeout(h.network, h.address, h.timeout)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h.conn = conn
+	return nil
+}
+
+// write `b` to the connection, connecting when necessary.
+func (h *Handler) write(b []byte) error {
+	if h.conn == nil {
+		if err := h.connect(); err != nil {
+			return err
+		}
+	}
+
+	h.conn.SetWriteDeadline(time.Now().Add(h.timeout))
+	_, err := h.conn.Write(b)
+	return err
+}
+
+// writeUDP compresses `b` and writes it in one or more chunks.
+func (h *Handler) writeUDP(b []byte) error {
+	b, err := h.compress(b)
+	if err != nil {
+		return err
+	}
+
+	if len(b) <= h.chunkSize {
+		return h.write(b)
+	}
+
+	size := h.chunkSize - chunkHeaderSize
+	count := (len(b) + size - 1) / size
+
+	if count > maxChunks {
+		return ErrTooLarge
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, h.chunkSize)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(b) {
+			end = len(b)
+		}
+
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, b[i*size:end]...)
+
+		if err := h.write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compress returns `b` compressed.
+func (h *Handler) compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch h.compression {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Zlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		return b, nil
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// message returns the GELF message for an entry.
+func (h *Handler) message(e *log.Entry) map[string]interface{} {
+	m := map[string]interface{}{}
+
+	addFields(m, "", h.fields)
+	addFields(m, "", e.Fields)
+
+	if e.Caller != nil {
+		m["_file"] = e.Caller.File
+		m["_line"] = e.Caller.Line
+		m["_function"] = e.Caller.Function
+	}
+
+	if h.facility != "" {
+		m["_facility"] = h.facility
+	}
+
+	m["version"] = "1.1"
+	m["host"] = h.host
+	m["short_message"] = e.Message
+	m["timestamp"] = json.Number(fmt.Sprintf("%.3f", float64(e.Timestamp.UnixNano()/1e6)/1e3))
+
+	if level, ok := h.levels[e.Level]; ok {
+		m["level"] = level
+	}
+
+	if i := strings.IndexByte(e.Message, '\n'); i >= 0 {
+		m["short_message"] = e.Message[:i]
+		m["full_message"] = e.Message
+	}
+
+	return m
+}
+
+// invalidChars matches characters not allowed in field names.
+var invalidChars = regexp.MustCompile(`[^\w.\-]`)
+
+// addFields adds `fields` to `m` as additional fields, with nested
+// fields joined by "_". Numbers and booleans keep their type, other
+// values are converted to strings.
+func addFields(m map[string]interface{}, prefix string, fields log.Fields) {
+	for k, v := range fields {
+		if prefix != "" {
+			k = prefix + "_" + k
+		}
+
+		switch v := v.(type) {
+		case log.Fields:
+			addFields(m, k, v)
+			continue
+		case map[string]interface{}:
+			addFields(m, k, log.Fields(v))
+			continue
+		}
+
+		name := invalidChars.ReplaceAllString(k, "_")
+
+		// _id is reserved
+		if name == "id" {
+			name = "id_"
+		}
+
+		m["_"+name] = value(v)
+	}
+}
+
+// value returns `v` as a number, boolean or string.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool, string:
+		return v
+	case float32:
+		return float(float64(v), v)
+	case float64:
+		return float(v, v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case error:
+		return safe.String(v, v.Error)
+	case fmt.Stringer:
+		return safe.String(v, v.String)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// float returns `v`, or the float `f` as a string when it is NaN or
+// infinite, which JSON cannot represent.
+func float(f float64, v interface{}) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return v
+	}
 }
@@ -0,0 +1,285 @@
+package graylog_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/graylog"
+)
+
+var entry = &log.Entry{
+	Level:     log.WarnLevel,
+	Message:   "upload failed\nstack",
+	Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC),
+	Caller:    &log.Frame{Function: "main.upload", File: "main.go", Line: 12},
+	Fields: log.Fields{
+		"user":     "tobi",
+		"size":     1024,
+		"ratio":    0.5,
+		"ok":       false,
+		"id":       "abc",
+		"bad key":  "x",
+		"error":    errors.New("boom"),
+		"duration": time.Second,
+		"request":  log.Fields{"method": "GET"},
+		"referrer": (*url.URL)(nil),
+		"nan":      math.NaN(),
+		"inf":      float32(math.Inf(-1)),
+	},
+}
+
+// listenUDP returns a UDP listener.
+func listenUDP(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// read reads a datagram.
+func read(t *testing.T, conn net.PacketConn) []byte {
+	b := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(b)
+	assert.NoError(t, err)
+	return b[:n]
+}
+
+// decode returns the GELF message in `b`.
+func decode(t *testing.T, b []byte) map[string]interface{} {
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &m))
+	return m
+}
+
+func Test(t *testing.T) {
+	conn := listenUDP(t)
+	defer conn.Close()
+
+	h, err := graylog.New("udp://"+conn.LocalAddr().String(),
+		graylog.WithCompression(graylog.None),
+		graylog.WithHost("web-1"),
+		graylog.WithFacility("api"),
+		graylog.WithFields(log.Fields{"env": "production"}))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(entry))
+
+	assert.Equal(t, map[string]interface{}{
+		"version":         "1.1",
+		"host":            "web-1",
+		"short_message":   "upload failed",
+		"full_message":    "upload failed\nstack",
+		"timestamp":       1577934245.006,
+		"level":           float64(4),
+		"_facility":       "api",
+		"_env":            "production",
+		"_user":           "tobi",
+		"_size":           float64(1024),
+		"_ratio":          0.5,
+		"_ok":             false,
+		"_id_":            "abc",
+		"_bad_key":        "x",
+		"_error":          "boom",
+		"_duration":       "1s",
+		"_request_method": "GET",
+		"_referrer":       "<nil>",
+		"_nan":            "NaN",
+		"_inf":            "-Infinity",
+		"_file":           "main.go",
+		"_line":           float64(12),
+		"_function":       "main.upload",
+	}, decode(t, read(t, conn)))
+}
+
+func TestHandler_compression(t *testing.T) {
+	conn := listenUDP(t)
+	defer conn.Close()
+
+	h, err := graylog.New("udp://" + conn.LocalAddr().String())
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "gzip"}))
+
+	r, err := gzip.NewReader(bytes.NewReader(read(t, conn)))
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", decode(t, b)["short_message"])
+
+	h, err = graylog.New("udp://"+conn.LocalAddr().String(), graylog.WithCompression(graylog.Zlib))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "zlib"}))
+
+	r2, err := zlib.NewReader(bytes.NewReader(read(t, conn)))
+	assert.NoError(t, err)
+	b, err = ioutil.ReadAll(r2)
+	assert.NoError(t, err)
+	assert.Equal(t, "zlib", decode(t, b)["short_message"])
+}
+
+func TestHandler_chunking(t *testing.T) {
+	conn := listenUDP(t)
+	defer conn.Close()
+
+	h, err := graylog.New("udp://"+conn.LocalAddr().String(),
+		graylog.WithCompression(graylog.None),
+		graylog.WithChunkSize(100))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	msg := strings.Repeat("x", 500)
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: msg}))
+
+	var id []byte
+	var count int
+	chunks := map[int][]byte{}
+
+	for {
+		b := read(t, conn)
+		assert.True(t, len(b) <= 100)
+		assert.Equal(t, []byte{0x1e, 0x0f}, b[:2])
+
+		if id == nil {
+			id = b[2:10]
+			count = int(b[11])
+		}
+
+		assert.Equal(t, id, b[2:10])
+		chunks[int(b[10])] = b[12:]
+
+		if len(chunks) == count {
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < count; i++ {
+		buf.Write(chunks[i])
+	}
+
+	assert.Equal(t, msg, decode(t, buf.Bytes())["short_message"])
+
+	h, err = graylog.New("udp://"+conn.LocalAddr().String(),
+		graylog.WithCompression(graylog.None),
+		graylog.WithChunkSize(13))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.Equal(t, graylog.ErrTooLarge, h.HandleLog(&log.Entry{Message: msg}))
+}
+
+// serve accepts connections on `l`, sending null-byte delimited messages
+// to the returned channel.
+func serve(l net.Listener) (<-chan string, <-chan net.Conn) {
+	messages := make(chan string, 10)
+	conns := make(chan net.Conn, 10)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					s, err := r.ReadString(0)
+					if err != nil {
+						return
+					}
+					messages <- strings.TrimSuffix(s, "\x00")
+				}
+			}()
+		}
+	}()
+
+	return messages, conns
+}
+
+func TestHandler_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	messages, conns := serve(l)
+
+	h, err := graylog.New("tcp://" + l.Addr().String())
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.ErrorLevel, Message: "hello"}))
+
+	m := decode(t, []byte(<-messages))
+	assert.Equal(t, "hello", m["short_message"])
+	assert.Equal(t, float64(3), m["level"])
+
+	// reconnects once the server closes the connection
+	(<-conns).Close()
+
+	for i := 0; i < 100; i++ {
+		h.HandleLog(&log.Entry{Message: "again"})
+
+		select {
+		case s := <-messages:
+			assert.Equal(t, "again", decode(t, []byte(s))["short_message"])
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("did not reconnect")
+}
+
+func TestHandler_tls(t *testing.T) {
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: s.TLS.Certificates})
+	assert.NoError(t, err)
+	defer l.Close()
+
+	messages, _ := serve(l)
+
+	h, err := graylog.New("tls://"+l.Addr().String(),
+		graylog.WithTLSConfig(s.Client().Transport.(*http.Transport).TLSClientConfig),
+		graylog.WithLevels(map[log.Level]int{log.InfoLevel: 5}))
+	assert.NoError(t, err)
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "secure"}))
+
+	m := decode(t, []byte(<-messages))
+	assert.Equal(t, "secure", m["short_message"])
+	assert.Equal(t, float64(5), m["level"])
+}
+
+func TestNew_errors(t *testing.T) {
+	_, err := graylog.New("http://localhost:12201")
+	assert.EqualError(t, err, `graylog: unsupported scheme "http"`)
+
+	_, err = graylog.New("udp://localhost:12201", graylog.WithChunkSize(12))
+	assert.EqualError(t, err, `graylog: chunk size 12 too small`)
+}
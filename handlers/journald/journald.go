@@ -0,0 +1,231 @@
+// Package journald implements a handler for the systemd journal, using its
+// native protocol to preserve fields as structured metadata.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// DefaultSocket is the path of the journal's native protocol socket.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// ErrUnsupported is returned on platforms without the journal.
+var ErrUnsupported = errors.New("journald: unsupported platform")
+
+// Priority returns the journal priority for a level.
+func Priority(l log.Level) int {
+	switch l {
+	case log.DebugLevel:
+		return 7
+	case log.InfoLevel:
+		return 6
+	case log.WarnLevel:
+		return 4
+	case log.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Handler implementation.
+type Handler struct {
+	socket     string
+	identifier string
+
+	mu   sync.Mutex
+	conn conn
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler. The socket is opened on the first entry.
+func New(options ...Option) *Handler {
+	v := &Handler{
+		socket:     DefaultSocket,
+		identifier: filepath.Base(os.Args[0]),
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	return v
+}
+
+// WithSocket sets the socket path, defaults to DefaultSocket.
+func WithSocket(path string) Option {
+	return func(v *Handler) {
+		v.socket = path
+	}
+}
+
+// WithIdentifier sets SYSLOG_IDENTIFIER, defaults to the program name.
+func WithIdentifier(s string) Option {
+	return func(v *Handler) {
+		v.identifier = s
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	b := h.encode(e)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn == nil {
+		c, err := dial(h.socket)
+		if err != nil {
+			return err
+		}
+		h.conn = c
+	}
+
+	return h.conn.send(b)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn == nil {
+		return nil
+	}
+
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+// conn is a connection to the journal.
+type conn interface {
+	send([]byte) error
+	Close() error
+}
+
+// encode returns the entry in the native protocol format.
+func (h *Handler) encode(e *log.Entry) []byte {
+	var b bytes.Buffer
+
+	writeField(&b, "MESSAGE", e.Message)
+	writeField(&b, "PRIORITY", strconv.Itoa(Priority(e.Level)))
+
+	if h.identifier != "" {
+		writeField(&b, "SYSLOG_IDENTIFIER", h.identifier)
+	}
+
+	if e.Caller != nil {
+		writeField(&b, "CODE_FILE", e.Caller.File)
+		writeField(&b, "CODE_LINE", strconv.Itoa(e.Caller.Line))
+		writeField(&b, "CODE_FUNC", e.Caller.Function)
+	}
+
+	fields := map[string]string{}
+	flatten(fields, "", e.Fields)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		writeField(&b, name, fields[name])
+	}
+
+	return b.Bytes()
+}
+
+// flatten adds `fields` to `v` by journal field name, with nested
+// fields joined by "_".
+func flatten(v map[string]string, prefix string, fields log.Fields) {
+	for k, value := range fields {
+		if prefix != "" {
+			k = prefix + "_" + k
+		}
+
+		switch value := value.(type) {
+		case log.Fields:
+			flatten(v, k, value)
+		case map[string]interface{}:
+			flatten(v, k, log.Fields(value))
+		default:
+			if name := fieldName(k); name != "" {
+				v[name] = fmt.Sprint(value)
+			}
+		}
+	}
+}
+
+// reserved field names written by the handler itself.
+var reserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// fieldName returns `s` as a valid journal field name: upper-case letters,
+// digits and underscores, not starting with an underscore or digit, at most
+// 64 characters. Invalid characters are replaced with underscores, and names
+// starting with a digit or reserved by the handler are prefixed with "F_".
+func fieldName(s string) string {
+	s = strings.TrimLeft(s, "_")
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+
+	if (len(b) > 0 && b[0] >= '0' && b[0] <= '9') || reserved[string(b)] {
+		b = append([]byte("F_"), b...)
+	}
+
+	if len(b) > 64 {
+		b = b[:64]
+	}
+
+	return string(b)
+}
+
+// writeField writes a field, using the binary format for values
+// containing newlines.
+func writeField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
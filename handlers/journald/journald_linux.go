@@ -0,0 +1,99 @@
+package journald
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// socket is a datagram socket sending to the journal.
+type socket struct {
+	*net.UnixConn
+	addr *net.UnixAddr
+}
+
+// dial returns a connection to the journal socket at `path`.
+func dial(path string) (conn, error) {
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &socket{
+		UnixConn: c,
+		addr:     &net.UnixAddr{Name: path, Net: "unixgram"},
+	}, nil
+}
+
+// send `b` as a datagram, or as a sealed memfd or temporary file when it
+// is too large for a datagram.
+func (s *socket) send(b []byte) error {
+	_, _, err := s.WriteMsgUnix(b, nil, s.addr)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	f, err := memfd(b)
+	if err != nil {
+		f, err = tempfile(b)
+		if err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+
+	_, _, err = s.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), s.addr)
+	return err
+}
+
+// memfd returns a sealed memfd containing `b`.
+func memfd(b []byte) (*os.File, error) {
+	fd, err := unix.MemfdCreate("journal", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(uintptr(fd), "journal")
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// tempfile returns an unlinked temporary file in /dev/shm containing `b`,
+// for kernels without memfd support.
+func tempfile(b []byte) (*os.File, error) {
+	f, err := ioutil.TempFile("/dev/shm", "journal.")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
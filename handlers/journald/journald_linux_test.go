@@ -0,0 +1,88 @@
+package journald
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+)
+
+// listen returns a stand-in for the journal socket, and a function
+// closing it.
+func listen(t *testing.T) (*net.UnixConn, string, func()) {
+	dir, err := ioutil.TempDir("", "journald")
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn, path, func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	conn, path, stop := listen(t)
+	defer stop()
+
+	h := New(WithSocket(path), WithIdentifier("app"))
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "hello", Fields: log.Fields{"user": "tobi"}}))
+
+	b := make([]byte, 1024)
+	n, err := conn.Read(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "MESSAGE=hello\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nUSER=tobi\n", string(b[:n]))
+}
+
+func TestHandler_large(t *testing.T) {
+	conn, path, stop := listen(t)
+	defer stop()
+
+	h := New(WithSocket(path))
+	defer h.Close()
+
+	msg := strings.Repeat("x", 4<<20)
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: msg}))
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	assert.NoError(t, err)
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	assert.NoError(t, err)
+	assert.Len(t, fds, 1)
+
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+
+	f.Seek(0, 0)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(b, []byte("MESSAGE="+msg+"\nPRIORITY=6\n")))
+}
+
+func TestHandler_unavailable(t *testing.T) {
+	h := New(WithSocket(filepath.Join(os.TempDir(), "journald-missing", "socket")))
+	defer h.Close()
+
+	assert.Error(t, h.HandleLog(&log.Entry{Message: "hello"}))
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package journald
+
+// dial returns ErrUnsupported, as the journal is only available on linux.
+func dial(path string) (conn, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,62 @@
+package journald
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+)
+
+func TestPriority(t *testing.T) {
+	assert.Equal(t, 7, Priority(log.DebugLevel))
+	assert.Equal(t, 6, Priority(log.InfoLevel))
+	assert.Equal(t, 4, Priority(log.WarnLevel))
+	assert.Equal(t, 3, Priority(log.ErrorLevel))
+	assert.Equal(t, 2, Priority(log.FatalLevel))
+}
+
+func TestHandler_encode(t *testing.T) {
+	h := New(WithIdentifier("app"))
+
+	b := h.encode(&log.Entry{
+		Level:   log.WarnLevel,
+		Message: "upload failed",
+		Caller:  &log.Frame{Function: "main.upload", File: "/src/main.go", Line: 12},
+		Fields: log.Fields{
+			"user":       "tobi",
+			"request":    log.Fields{"id": 5},
+			"_private":   true,
+			"2fa":        "totp",
+			"user-agent": "curl",
+			"trace":      "a\nb",
+			"message":    "spoofed",
+			"priority":   0,
+			"code_line":  1,
+			"code": log.Fields{
+				"file": "spoofed.go",
+				"func": "main.spoofed",
+			},
+			"syslog_identifier": "spoofed",
+		},
+	})
+
+	assert.Equal(t, "MESSAGE=upload failed\n"+
+		"PRIORITY=4\n"+
+		"SYSLOG_IDENTIFIER=app\n"+
+		"CODE_FILE=/src/main.go\n"+
+		"CODE_LINE=12\n"+
+		"CODE_FUNC=main.upload\n"+
+		"F_2FA=totp\n"+
+		"F_CODE_FILE=spoofed.go\n"+
+		"F_CODE_FUNC=main.spoofed\n"+
+		"F_CODE_LINE=1\n"+
+		"F_MESSAGE=spoofed\n"+
+		"F_PRIORITY=0\n"+
+		"F_SYSLOG_IDENTIFIER=spoofed\n"+
+		"PRIVATE=true\n"+
+		"REQUEST_ID=5\n"+
+		"TRACE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"+
+		"USER=tobi\n"+
+		"USER_AGENT=curl\n", string(b))
+}
@@ -50,3 +50,9 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 	key := base64.StdEncoding.EncodeToString(uuid[:])
 	return h.producer.Put(b, key)
 }
+
+// Close stops the producer, flushing any buffered records.
+func (h *Handler) Close() error {
+	h.producer.Stop()
+	return nil
+}
@@ -25,3 +25,13 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 
 	return h.Handler.HandleLog(e)
 }
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	return log.FlushHandler(h.Handler)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	return log.CloseHandler(h.Handler)
+}
@@ -37,6 +37,10 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 	h.enc.EncodeKeyval("level", e.Level.String())
 	h.enc.EncodeKeyval("message", e.Message)
 
+	if e.Caller != nil {
+		h.enc.EncodeKeyval("caller", e.Caller.String())
+	}
+
 	for _, name := range names {
 		h.enc.EncodeKeyval(name, e.Fields.Get(name))
 	}
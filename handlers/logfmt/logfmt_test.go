@@ -34,6 +34,19 @@ timestamp=1970-01-01T00:00:00Z level=error message=boom
 	assert.Equal(t, expected, buf.String())
 }
 
+func TestCaller(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := logfmt.New(&buf)
+	h.HandleLog(&log.Entry{
+		Level:   log.InfoLevel,
+		Message: "hello",
+		Caller:  &log.Frame{File: "/go/src/app/main.go", Line: 12},
+	})
+
+	assert.Equal(t, "timestamp=0001-01-01T00:00:00Z level=info message=hello caller=app/main.go:12\n", buf.String())
+}
+
 func Benchmark(b *testing.B) {
 	log.SetHandler(logfmt.New(ioutil.Discard))
 	ctx := log.WithField("user", "tj").WithField("id", "123")
@@ -0,0 +1,417 @@
+// Package loki implements a Grafana Loki handler, batching entries and
+// pushing them with the JSON or snappy-compressed protobuf push API.
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/batch"
+	"github.com/apex/log/internal/protowire"
+	"github.com/apex/log/internal/push"
+)
+
+// Format of push requests.
+type Format int
+
+// Formats available.
+const (
+	JSON Format = iota
+	Protobuf
+)
+
+// Handler implementation.
+type Handler struct {
+	*batch.Handler
+
+	url            string
+	format         Format
+	labels         map[string]string
+	labelFields    []string
+	maxLabelValues int
+	tenant         string
+	username       string
+	password       string
+	client         *http.Client
+	batchOptions   []batch.Option
+
+	// seen label values of promoted fields, only accessed by the batch worker
+	seen map[string]map[string]bool
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler pushing to the Loki push API at `url`, such as
+// "http://localhost:3100/loki/api/v1/push".
+func New(url string, options ...Option) *Handler {
+	v := &Handler{
+		url:            url,
+		labels:         map[string]string{"job": filepath.Base(os.Args[0])},
+		maxLabelValues: 100,
+		client:         http.DefaultClient,
+		seen:           map[string]map[string]bool{},
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	opts := append([]batch.Option{batch.WithName("loki")}, v.batchOptions...)
+	v.Handler = batch.New(v.push, opts...)
+
+	return v
+}
+
+// WithFormat sets the push format, defaults to JSON.
+func WithFormat(f Format) Option {
+	return func(v *Handler) {
+		v.format = f
+	}
+}
+
+// WithLabels sets the static stream labels, defaults to {job="<program name>"}.
+func WithLabels(labels map[string]string) Option {
+	return func(v *Handler) {
+		v.labels = labels
+	}
+}
+
+// WithLabelFields promotes fields to stream labels. The name "level" refers
+// to the entry level. Promoted fields are removed from the log line.
+func WithLabelFields(names ...string) Option {
+	return func(v *Handler) {
+		v.labelFields = append(v.labelFields, names...)
+	}
+}
+
+// WithMaxLabelValues limits the number of distinct values of each promoted
+// field, guarding against high cardinality streams. Further values are
+// kept in the log line instead. Defaults to 100.
+func WithMaxLabelValues(n int) Option {
+	return func(v *Handler) {
+		v.maxLabelValues = n
+	}
+}
+
+// WithTenant sets the X-Scope-OrgID tenant header.
+func WithTenant(id string) Option {
+	return func(v *Handler) {
+		v.tenant = id
+	}
+}
+
+// WithBasicAuth sets basic auth credentials.
+func WithBasicAuth(username, password string) Option {
+	return func(v *Handler) {
+		v.username = username
+		v.password = password
+	}
+}
+
+// WithClient sets the HTTP client, defaults to http.DefaultClient.
+func WithClient(c *http.Client) Option {
+	return func(v *Handler) {
+		v.client = c
+	}
+}
+
+// WithBatchOptions sets options of the underlying batch handler.
+func WithBatchOptions(options ...batch.Option) Option {
+	return func(v *Handler) {
+		v.batchOptions = append(v.batchOptions, options...)
+	}
+}
+
+// stream of entries with the same labels.
+type stream struct {
+	labels  map[string]string
+	entries []streamEntry
+}
+
+// streamEntry is a log line.
+type streamEntry struct {
+	timestamp int64
+	line      string
+}
+
+// push sends a batch of entries.
+func (h *Handler) push(ctx context.Context, entries []*log.Entry) error {
+	var body []byte
+	var contentType string
+
+	streams := h.streams(entries)
+
+	switch h.format {
+	case Protobuf:
+		body = snappyEncode(encodeProtobuf(streams))
+		contentType = "application/x-protobuf"
+	default:
+		b, err := encodeJSON(streams)
+		if err != nil {
+			return batch.Permanent(err)
+		}
+		body = b
+		contentType = "application/json"
+	}
+
+	return push.Post(ctx, h.client, h.url, body, func(r *http.Request) {
+		r.Header.Set("Content-Type", contentType)
+
+		if h.tenant != "" {
+			r.Header.Set("X-Scope-OrgID", h.tenant)
+		}
+
+		if h.username != "" || h.password != "" {
+			r.SetBasicAuth(h.username, h.password)
+		}
+	})
+}
+
+// streams returns the entries grouped by labels, in order of first appearance.
+func (h *Handler) streams(entries []*log.Entry) []*stream {
+	var streams []*stream
+	byKey := map[string]*stream{}
+
+	for _, e := range entries {
+		labels, fields := h.labelsOf(e)
+		key := labelString(labels)
+
+		s, ok := byKey[key]
+		if !ok {
+			s = &stream{labels: labels}
+			byKey[key] = s
+			streams = append(streams, s)
+		}
+
+		s.entries = append(s.entries, streamEntry{
+			timestamp: e.Timestamp.UnixNano(),
+			line:      line(e, fields),
+		})
+	}
+
+	return streams
+}
+
+// labelsOf returns the labels of an entry, and its remaining fields.
+func (h *Handler) labelsOf(e *log.Entry) (map[string]string, log.Fields) {
+	labels := make(map[string]string, len(h.labels)+len(h.labelFields))
+	for k, v := range h.labels {
+		labels[k] = v
+	}
+
+	fields := e.Fields
+
+	for _, name := range h.labelFields {
+		var value string
+
+		if name == "level" {
+			value = e.Level.String()
+		} else {
+			v, ok := fields[name]
+			if !ok {
+				continue
+			}
+			value = fmt.Sprint(v)
+		}
+
+		if !h.allow(name, value) {
+			continue
+		}
+
+		labels[name] = value
+
+		if _, ok := fields[name]; ok {
+			fields = without(fields, name)
+		}
+	}
+
+	return labels, fields
+}
+
+// allow returns true if `value` may be used for the label `name`.
+func (h *Handler) allow(name, value string) bool {
+	values, ok := h.seen[name]
+	if !ok {
+		values = map[string]bool{}
+		h.seen[name] = values
+	}
+
+	if values[value] {
+		return true
+	}
+
+	if h.maxLabelValues > 0 && len(values) >= h.maxLabelValues {
+		return false
+	}
+
+	values[value] = true
+	return true
+}
+
+// without returns a copy of `fields` without `name`.
+func without(fields log.Fields, name string) log.Fields {
+	f := make(log.Fields, len(fields))
+	for k, v := range fields {
+		if k != name {
+			f[k] = v
+		}
+	}
+	return f
+}
+
+// line returns the log line for an entry in logfmt. Nested fields are
+// flattened to dotted names, and values logfmt does not support, such
+// as slices, are formatted with fmt.
+func line(e *log.Entry, fields log.Fields) string {
+	var b bytes.Buffer
+	enc := logfmt.NewEncoder(&b)
+
+	enc.EncodeKeyval("level", e.Level.String())
+	enc.EncodeKeyval("message", e.Message)
+
+	if e.Caller != nil {
+		enc.EncodeKeyval("caller", e.Caller.String())
+	}
+
+	flat := log.Fields{}
+	flatten(flat, "", fields)
+
+	for _, name := range flat.Names() {
+		if err := enc.EncodeKeyval(name, flat[name]); err != nil {
+			enc.EncodeKeyval(name, fmt.Sprint(flat[name]))
+		}
+	}
+
+	return b.String()
+}
+
+// flatten adds `fields` to `v`, with nested fields joined by ".".
+func flatten(v log.Fields, prefix string, fields log.Fields) {
+	for k, value := range fields {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+
+		switch value := value.(type) {
+		case log.Fields:
+			flatten(v, k, value)
+		case map[string]interface{}:
+			flatten(v, k, log.Fields(value))
+		default:
+			v[k] = value
+		}
+	}
+}
+
+// labelString returns labels in the Prometheus format, such as
+// `{job="api", level="info"}`.
+func labelString(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(labelName(name))
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+	}
+
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelName returns `s` as a valid label name, replacing invalid characters.
+func labelName(s string) string {
+	b := []byte(s)
+
+	for i, c := range b {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+
+	if len(b) == 0 {
+		return "_"
+	}
+
+	return string(b)
+}
+
+// encodeJSON returns the streams as a JSON push request.
+func encodeJSON(streams []*stream) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	var req struct {
+		Streams []jsonStream `json:"streams"`
+	}
+
+	for _, s := range streams {
+		labels := make(map[string]string, len(s.labels))
+		for k, v := range s.labels {
+			labels[labelName(k)] = v
+		}
+
+		js := jsonStream{Stream: labels}
+		for _, e := range s.entries {
+			js.Values = append(js.Values, [2]string{strconv.FormatInt(e.timestamp, 10), e.line})
+		}
+
+		req.Streams = append(req.Streams, js)
+	}
+
+	return json.Marshal(req)
+}
+
+// encodeProtobuf returns the streams as a protobuf push request:
+//
+//	message PushRequest { repeated Stream streams = 1; }
+//	message Stream { string labels = 1; repeated Entry entries = 2; }
+//	message Entry { Timestamp timestamp = 1; string line = 2; }
+//	message Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func encodeProtobuf(streams []*stream) []byte {
+	var req, s, e, ts []byte
+
+	for _, v := range streams {
+		s = protowire.AppendStringField(s[:0], 1, labelString(v.labels))
+
+		for _, entry := range v.entries {
+			ts = protowire.AppendVarintField(ts[:0], 1, uint64(entry.timestamp/1e9))
+			ts = protowire.AppendVarintField(ts, 2, uint64(entry.timestamp%1e9))
+
+			e = protowire.AppendBytesField(e[:0], 1, ts)
+			e = protowire.AppendStringField(e, 2, entry.line)
+
+			s = protowire.AppendBytesField(s, 2, e)
+		}
+
+		req = protowire.AppendBytesField(req, 1, s)
+	}
+
+	return req
+}
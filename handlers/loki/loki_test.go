@@ -0,0 +1,261 @@
+package loki
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/batch"
+	"github.com/apex/log/internal/protowire"
+	"github.com/apex/log/internal/push/pushtest"
+)
+
+var timestamp = time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+func entry(level log.Level, msg string, fields log.Fields) *log.Entry {
+	return &log.Entry{
+		Level:     level,
+		Message:   msg,
+		Timestamp: timestamp,
+		Fields:    fields,
+	}
+}
+
+func Test(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent)
+	defer s.Close()
+
+	h := New(s.URL+"/loki/api/v1/push",
+		WithLabels(map[string]string{"job": "api"}),
+		WithLabelFields("level", "app"),
+		WithTenant("team-a"),
+		WithBasicAuth("tobi", "ferret"))
+
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "hello", log.Fields{"app": "web", "user": "tobi"})))
+	assert.NoError(t, h.HandleLog(entry(log.ErrorLevel, "boom", log.Fields{"app": "web"})))
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "world", log.Fields{"app": "web"})))
+	assert.NoError(t, h.Close())
+
+	assert.Len(t, s.Requests, 1)
+	r := s.Requests[0]
+	assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "team-a", r.Header.Get("X-Scope-OrgID"))
+
+	user, pass, ok := r.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "tobi", user)
+	assert.Equal(t, "ferret", pass)
+
+	assert.JSONEq(t, `{
+		"streams": [
+			{
+				"stream": {"job": "api", "level": "info", "app": "web"},
+				"values": [
+					["1577934245000000006", "level=info message=hello user=tobi"],
+					["1577934245000000006", "level=info message=world"]
+				]
+			},
+			{
+				"stream": {"job": "api", "level": "error", "app": "web"},
+				"values": [
+					["1577934245000000006", "level=error message=boom"]
+				]
+			}
+		]
+	}`, string(s.Bodies[0]))
+}
+
+func TestLine(t *testing.T) {
+	e := entry(log.InfoLevel, "upload", nil)
+	e.Caller = &log.Frame{File: "main.go", Line: 12}
+
+	s := line(e, log.Fields{
+		"user":    "tobi",
+		"request": log.Fields{"id": 5, "headers": map[string]interface{}{"accept": "*/*"}},
+		"errors":  []string{"a", "b"},
+		"stack":   []log.Frame{{File: "main.go", Line: 12}},
+	})
+
+	assert.Equal(t, `level=info message=upload caller=main.go:12 errors="[a b]" request.headers.accept=*/* request.id=5 stack=[main.go:12] user=tobi`, s)
+}
+
+func TestHandler_maxLabelValues(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent)
+	defer s.Close()
+
+	h := New(s.URL, WithLabelFields("user"), WithMaxLabelValues(1))
+
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "a", log.Fields{"user": "tobi"})))
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "b", log.Fields{"user": "loki"})))
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "c", log.Fields{"user": "tobi"})))
+	assert.NoError(t, h.Close())
+
+	var req struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+
+	assert.NoError(t, json.Unmarshal(s.Bodies[0], &req))
+	assert.Len(t, req.Streams, 2)
+	assert.Equal(t, "tobi", req.Streams[0].Stream["user"])
+	assert.Len(t, req.Streams[0].Values, 2)
+	assert.Equal(t, "", req.Streams[1].Stream["user"])
+	assert.Equal(t, "level=info message=b user=loki", req.Streams[1].Values[0][1])
+}
+
+func TestHandler_protobuf(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent)
+	defer s.Close()
+
+	h := New(s.URL,
+		WithFormat(Protobuf),
+		WithLabels(map[string]string{"job": "api", "bad-name": "a\"b"}))
+
+	assert.NoError(t, h.HandleLog(entry(log.WarnLevel, "hello", nil)))
+	assert.NoError(t, h.Close())
+
+	assert.Equal(t, "application/x-protobuf", s.Requests[0].Header.Get("Content-Type"))
+
+	b, err := snappyDecode(s.Bodies[0])
+	assert.NoError(t, err)
+
+	req, err := protowire.ConsumeFields(b)
+	assert.NoError(t, err)
+	assert.Len(t, req, 1)
+	assert.Equal(t, 1, req[0].Num)
+
+	stream, err := protowire.ConsumeFields(req[0].Bytes)
+	assert.NoError(t, err)
+	assert.Len(t, stream, 2)
+	assert.Equal(t, `{bad_name="a\"b", job="api"}`, string(stream[0].Bytes))
+
+	e, err := protowire.ConsumeFields(stream[1].Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "level=warn message=hello", string(e[1].Bytes))
+
+	ts, err := protowire.ConsumeFields(e[0].Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1577934245), ts[0].Value)
+	assert.Equal(t, uint64(6), ts[1].Value)
+}
+
+func TestHandler_retry(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	defer s.Close()
+
+	h := New(s.URL, WithBatchOptions(batch.WithRetries(3, time.Millisecond)))
+
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "hello", nil)))
+	assert.NoError(t, h.Close())
+	assert.Len(t, s.Requests, 3)
+}
+
+func TestHandler_permanent(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent, http.StatusBadRequest)
+	defer s.Close()
+
+	var errs []error
+	h := New(s.URL, WithBatchOptions(
+		batch.WithRetries(3, time.Millisecond),
+		batch.WithErrorHandler(func(err error) { errs = append(errs, err) })))
+
+	assert.NoError(t, h.HandleLog(entry(log.InfoLevel, "hello", nil)))
+
+	err := h.Close()
+	assert.EqualError(t, err, "loki: 400 Bad Request: error details")
+	assert.Len(t, s.Requests, 1)
+	assert.Len(t, errs, 1)
+
+	var e *log.HandlerError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, 1, e.Entries)
+}
+
+func TestSnappy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	random := make([]byte, 100000)
+	r.Read(random)
+
+	cases := [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("hello hello hello hello world"),
+		[]byte(strings.Repeat("level=info message=hello user=tobi\n", 5000)),
+		random,
+	}
+
+	for _, c := range cases {
+		b := snappyEncode(c)
+		v, err := snappyDecode(b)
+		assert.NoError(t, err)
+		assert.Equal(t, string(c), string(v))
+	}
+
+	assert.True(t, len(snappyEncode(cases[3])) < len(cases[3])/10)
+}
+
+// snappyDecode decodes the snappy block format.
+func snappyDecode(src []byte) ([]byte, error) {
+	n, i := binary.Uvarint(src)
+	if i <= 0 {
+		return nil, errors.New("invalid length")
+	}
+
+	dst := make([]byte, 0, n)
+	src = src[i:]
+
+	for len(src) > 0 {
+		tag := src[0]
+
+		switch tag & 3 {
+		case 0:
+			l := int(tag >> 2)
+			src = src[1:]
+
+			switch l {
+			case 60:
+				l = int(src[0])
+				src = src[1:]
+			case 61:
+				l = int(src[0]) | int(src[1])<<8
+				src = src[2:]
+			}
+
+			l++
+			dst = append(dst, src[:l]...)
+			src = src[l:]
+		case 2:
+			l := int(tag>>2) + 1
+			offset := int(src[1]) | int(src[2])<<8
+			src = src[3:]
+
+			if offset == 0 || offset > len(dst) {
+				return nil, errors.New("invalid offset")
+			}
+
+			for j := 0; j < l; j++ {
+				dst = append(dst, dst[len(dst)-offset])
+			}
+		default:
+			return nil, errors.New("unsupported tag")
+		}
+	}
+
+	if uint64(len(dst)) != n {
+		return nil, errors.New("invalid length")
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,97 @@
+package loki
+
+import "encoding/binary"
+
+// snappy block format constants.
+const (
+	snappyBlockSize  = 1 << 16
+	snappyTagLiteral = 0x00
+	snappyTagCopy2   = 0x02
+	snappyMinMatch   = 4
+	snappyMaxCopy    = 64
+	snappyHashBits   = 14
+)
+
+// snappyEncode returns `src` compressed in the snappy block format, as
+// expected by the Loki push API. Matches are found with a simple hash
+// table, trading compression ratio for simplicity.
+func snappyEncode(src []byte) []byte {
+	dst := make([]byte, binary.MaxVarintLen64, len(src)/2+16)
+	n := binary.PutUvarint(dst, uint64(len(src)))
+	dst = dst[:n]
+
+	for len(src) > 0 {
+		block := src
+		if len(block) > snappyBlockSize {
+			block = block[:snappyBlockSize]
+		}
+		dst = snappyEncodeBlock(dst, block)
+		src = src[len(block):]
+	}
+
+	return dst
+}
+
+// snappyEncodeBlock appends the elements for `src`, at most 64KiB, to `dst`.
+func snappyEncodeBlock(dst, src []byte) []byte {
+	var table [1 << snappyHashBits]int32
+	for i := range table {
+		table[i] = -1
+	}
+
+	var lit int
+
+	for i := 0; i+snappyMinMatch <= len(src); {
+		v := binary.LittleEndian.Uint32(src[i:])
+		h := (v * 0x1e35a7bd) >> (32 - snappyHashBits)
+		c := int(table[h])
+		table[h] = int32(i)
+
+		if c < 0 || binary.LittleEndian.Uint32(src[c:]) != v {
+			i++
+			continue
+		}
+
+		n := snappyMinMatch
+		for i+n < len(src) && src[c+n] == src[i+n] {
+			n++
+		}
+
+		dst = snappyLiteral(dst, src[lit:i])
+
+		for m := n; m > 0; {
+			l := m
+			if l > snappyMaxCopy {
+				l = snappyMaxCopy
+			}
+			if m-l > 0 && m-l < snappyMinMatch {
+				l = m - snappyMinMatch
+			}
+			dst = append(dst, byte(l-1)<<2|snappyTagCopy2, byte(i-c), byte((i-c)>>8))
+			m -= l
+		}
+
+		i += n
+		lit = i
+	}
+
+	return snappyLiteral(dst, src[lit:])
+}
+
+// snappyLiteral appends a literal element for `lit`, if non-empty.
+func snappyLiteral(dst, lit []byte) []byte {
+	n := len(lit) - 1
+
+	switch {
+	case n < 0:
+		return dst
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyTagLiteral, byte(n))
+	default:
+		dst = append(dst, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	}
+
+	return append(dst, lit...)
+}
@@ -2,12 +2,52 @@
 package multi
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/apex/log"
+	"github.com/apex/log/internal/safe"
 )
 
+// ErrTimeout is returned for handlers which did not complete within the timeout.
+var ErrTimeout = errors.New("timeout")
+
+// Error is returned when one or more handlers fail, with
+// a *log.HandlerError for each failure.
+type Error struct {
+	Errors []error
+}
+
+// Error implementation.
+func (e *Error) Error() string {
+	var s []string
+	for _, err := range e.Errors {
+		s = append(s, err.Error())
+	}
+
+	if len(s) == 1 {
+		return s[0]
+	}
+
+	return fmt.Sprintf("%d handlers failed: %s", len(s), strings.Join(s, "; "))
+}
+
+// Unwrap returns the errors.
+func (e *Error) Unwrap() []error {
+	return e.Errors
+}
+
 // Handler implementation.
+//
+// Every handler is invoked, even when others fail or panic. When Parallel is
+// true the handlers are invoked concurrently, waiting at most Timeout
+// when it is non-zero.
 type Handler struct {
 	Handlers []log.Handler
+	Parallel bool
+	Timeout  time.Duration
 }
 
 // New handler.
@@ -17,16 +57,112 @@ func New(h ...log.Handler) *Handler {
 	}
 }
 
+// NewParallel handler invoking handlers concurrently, waiting at most `timeout`
+// when it is non-zero.
+func NewParallel(timeout time.Duration, h ...log.Handler) *Handler {
+	return &Handler{
+		Handlers: h,
+		Parallel: true,
+		Timeout:  timeout,
+	}
+}
+
 // HandleLog implements log.Handler.
 func (h *Handler) HandleLog(e *log.Entry) error {
-	for _, handler := range h.Handlers {
-		// TODO(tj): maybe just write to stderr here, definitely not ideal
-		// to miss out logging to a more critical handler if something
-		// goes wrong
-		if err := handler.HandleLog(e); err != nil {
-			return err
+	if h.Parallel {
+		return h.handleParallel(e)
+	}
+
+	errs := make([]error, len(h.Handlers))
+
+	for i, handler := range h.Handlers {
+		errs[i] = safe.HandleLog(handler, e)
+	}
+
+	return errorOf(h.Handlers, errs, 1)
+}
+
+// result of a handler.
+type result struct {
+	i   int
+	err error
+}
+
+// handleParallel invokes the handlers concurrently.
+func (h *Handler) handleParallel(e *log.Entry) error {
+	results := make(chan result, len(h.Handlers))
+
+	for i, handler := range h.Handlers {
+		go func(i int, handler log.Handler) {
+			results <- result{i, safe.HandleLog(handler, e)}
+		}(i, handler)
+	}
+
+	var timeout <-chan time.Time
+	if h.Timeout > 0 {
+		timer := time.NewTimer(h.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	errs := make([]error, len(h.Handlers))
+	done := make([]bool, len(h.Handlers))
+
+loop:
+	for range h.Handlers {
+		select {
+		case r := <-results:
+			done[r.i] = true
+			errs[r.i] = r.err
+		case <-timeout:
+			for i, ok := range done {
+				if !ok {
+					errs[i] = ErrTimeout
+				}
+			}
+			break loop
 		}
 	}
 
-	return nil
+	return errorOf(h.Handlers, errs, 1)
+}
+
+// Flush implements log.Flusher, flushing all handlers.
+func (h *Handler) Flush() error {
+	errs := make([]error, len(h.Handlers))
+
+	for i, handler := range h.Handlers {
+		errs[i] = log.FlushHandler(handler)
+	}
+
+	return errorOf(h.Handlers, errs, 0)
+}
+
+// Close implements log.Closer, closing all handlers.
+func (h *Handler) Close() error {
+	errs := make([]error, len(h.Handlers))
+
+	for i, handler := range h.Handlers {
+		errs[i] = log.CloseHandler(handler)
+	}
+
+	return errorOf(h.Handlers, errs, 0)
+}
+
+// errorOf returns an *Error for the non-nil errors in `errs`, returned by the
+// handler at the same index in `handlers` losing `entries`, or nil.
+func errorOf(handlers []log.Handler, errs []error, entries int) error {
+	var v []error
+
+	for i, err := range errs {
+		if err != nil {
+			v = append(v, log.NewHandlerError(handlers[i], err, entries))
+		}
+	}
+
+	if len(v) == 0 {
+		return nil
+	}
+
+	return &Error{Errors: v}
 }
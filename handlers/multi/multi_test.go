@@ -1,6 +1,7 @@
 package multi_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -29,3 +30,63 @@ func Test(t *testing.T) {
 	assert.Len(t, a.Entries, 3)
 	assert.Len(t, b.Entries, 3)
 }
+
+// failing handler.
+type failing struct {
+	err error
+}
+
+func (f failing) HandleLog(e *log.Entry) error {
+	return f.err
+}
+
+// panicking handler.
+type panicking struct{}
+
+func (panicking) HandleLog(e *log.Entry) error {
+	panic("boom")
+}
+
+// slow handler.
+type slow struct {
+	memory.Handler
+	delay time.Duration
+}
+
+func (s *slow) HandleLog(e *log.Entry) error {
+	time.Sleep(s.delay)
+	return s.Handler.HandleLog(e)
+}
+
+func TestErrors(t *testing.T) {
+	a := memory.New()
+	b := memory.New()
+
+	h := multi.New(failing{errors.New("unavailable")}, a, panicking{}, b)
+
+	err := h.HandleLog(&log.Entry{Message: "hello"})
+	assert.EqualError(t, err, "2 handlers failed: multi_test.failing: unavailable; multi_test.panicking: panic: boom")
+	assert.Len(t, a.Entries, 1)
+	assert.Len(t, b.Entries, 1)
+
+	var e *multi.Error
+	assert.True(t, errors.As(err, &e))
+	assert.Len(t, e.Errors, 2)
+
+	var he *log.HandlerError
+	assert.True(t, errors.As(e.Errors[1], &he))
+	assert.Equal(t, "multi_test.panicking", he.Handler)
+}
+
+func TestParallel(t *testing.T) {
+	a := &slow{delay: 10 * time.Millisecond}
+	b := &slow{delay: time.Second}
+
+	h := multi.NewParallel(100*time.Millisecond, a, failing{errors.New("unavailable")}, b)
+
+	start := time.Now()
+	err := h.HandleLog(&log.Entry{Message: "hello"})
+	assert.True(t, time.Since(start) < time.Second)
+	assert.EqualError(t, err, "2 handlers failed: multi_test.failing: unavailable; *multi_test.slow: timeout")
+	assert.Len(t, a.Entries, 1)
+}
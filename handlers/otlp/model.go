@@ -0,0 +1,313 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/internal/protowire"
+	"github.com/apex/log/internal/safe"
+)
+
+// request is an export request with a single resource and scope.
+type request struct {
+	resource []keyValue
+	records  []record
+}
+
+// record is a log record.
+type record struct {
+	time         uint64
+	severity     int
+	severityText string
+	body         string
+	attributes   []keyValue
+	traceID      []byte
+	spanID       []byte
+}
+
+// kind of value.
+type kind int
+
+// Kinds of values.
+const (
+	stringKind kind = iota
+	boolKind
+	intKind
+	doubleKind
+	arrayKind
+	kvlistKind
+	bytesKind
+)
+
+// anyValue is an attribute value.
+type anyValue struct {
+	kind   kind
+	s      string
+	b      bool
+	i      int64
+	f      float64
+	bytes  []byte
+	array  []anyValue
+	kvlist []keyValue
+}
+
+// keyValue is an attribute.
+type keyValue struct {
+	key   string
+	value anyValue
+}
+
+// attributes returns `fields` as attributes, sorted by key.
+func attributes(fields log.Fields) []keyValue {
+	attrs := make([]keyValue, 0, len(fields))
+
+	for k, v := range fields {
+		attrs = append(attrs, keyValue{key: k, value: valueOf(v)})
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].key < attrs[j].key
+	})
+
+	return attrs
+}
+
+// valueOf returns `v` as an attribute value, preserving its type where
+// possible and falling back to its string representation.
+func valueOf(v interface{}) anyValue {
+	switch v := v.(type) {
+	case nil:
+		return anyValue{kind: stringKind}
+	case string:
+		return anyValue{kind: stringKind, s: v}
+	case bool:
+		return anyValue{kind: boolKind, b: v}
+	case int:
+		return anyValue{kind: intKind, i: int64(v)}
+	case int8:
+		return anyValue{kind: intKind, i: int64(v)}
+	case int16:
+		return anyValue{kind: intKind, i: int64(v)}
+	case int32:
+		return anyValue{kind: intKind, i: int64(v)}
+	case int64:
+		return anyValue{kind: intKind, i: v}
+	case uint:
+		return anyValue{kind: intKind, i: int64(v)}
+	case uint8:
+		return anyValue{kind: intKind, i: int64(v)}
+	case uint16:
+		return anyValue{kind: intKind, i: int64(v)}
+	case uint32:
+		return anyValue{kind: intKind, i: int64(v)}
+	case uint64:
+		return anyValue{kind: intKind, i: int64(v)}
+	case float32:
+		return anyValue{kind: doubleKind, f: float64(v)}
+	case float64:
+		return anyValue{kind: doubleKind, f: v}
+	case []byte:
+		return anyValue{kind: bytesKind, bytes: v}
+	case log.Fields:
+		return anyValue{kind: kvlistKind, kvlist: attributes(v)}
+	case map[string]interface{}:
+		return anyValue{kind: kvlistKind, kvlist: attributes(v)}
+	case time.Time:
+		return anyValue{kind: stringKind, s: v.Format(time.RFC3339Nano)}
+	case error:
+		return anyValue{kind: stringKind, s: safe.String(v, v.Error)}
+	case fmt.Stringer:
+		return anyValue{kind: stringKind, s: safe.String(v, v.String)}
+	}
+
+	if r := reflect.ValueOf(v); r.Kind() == reflect.Slice || r.Kind() == reflect.Array {
+		array := make([]anyValue, r.Len())
+		for i := range array {
+			array[i] = valueOf(r.Index(i).Interface())
+		}
+		return anyValue{kind: arrayKind, array: array}
+	}
+
+	return anyValue{kind: stringKind, s: fmt.Sprint(v)}
+}
+
+// marshalProtobuf returns the request as an ExportLogsServiceRequest.
+func (r *request) marshalProtobuf() []byte {
+	var resource, scope, scopeLogs, resourceLogs []byte
+
+	for _, kv := range r.resource {
+		resource = protowire.AppendBytesField(resource, 1, kv.appendProtobuf(nil))
+	}
+
+	scope = protowire.AppendStringField(scope, 1, ScopeName)
+	scopeLogs = protowire.AppendBytesField(scopeLogs, 1, scope)
+
+	for _, rec := range r.records {
+		scopeLogs = protowire.AppendBytesField(scopeLogs, 2, rec.appendProtobuf(nil))
+	}
+
+	resourceLogs = protowire.AppendBytesField(resourceLogs, 1, resource)
+	resourceLogs = protowire.AppendBytesField(resourceLogs, 2, scopeLogs)
+
+	return protowire.AppendBytesField(nil, 1, resourceLogs)
+}
+
+// appendProtobuf appends the record as a LogRecord.
+func (r *record) appendProtobuf(b []byte) []byte {
+	b = protowire.AppendFixed64Field(b, 1, r.time)
+	b = protowire.AppendVarintField(b, 2, uint64(r.severity))
+	b = protowire.AppendStringField(b, 3, r.severityText)
+	b = protowire.AppendBytesField(b, 5, anyValue{kind: stringKind, s: r.body}.appendProtobuf(nil))
+
+	for _, kv := range r.attributes {
+		b = protowire.AppendBytesField(b, 6, kv.appendProtobuf(nil))
+	}
+
+	if r.traceID != nil {
+		b = protowire.AppendBytesField(b, 9, r.traceID)
+	}
+
+	if r.spanID != nil {
+		b = protowire.AppendBytesField(b, 10, r.spanID)
+	}
+
+	return protowire.AppendFixed64Field(b, 11, r.time)
+}
+
+// appendProtobuf appends the attribute as a KeyValue.
+func (kv keyValue) appendProtobuf(b []byte) []byte {
+	b = protowire.AppendStringField(b, 1, kv.key)
+	return protowire.AppendBytesField(b, 2, kv.value.appendProtobuf(nil))
+}
+
+// appendProtobuf appends the value as an AnyValue.
+func (v anyValue) appendProtobuf(b []byte) []byte {
+	switch v.kind {
+	case boolKind:
+		var n uint64
+		if v.b {
+			n = 1
+		}
+		return protowire.AppendVarintField(b, 2, n)
+	case intKind:
+		return protowire.AppendVarintField(b, 3, uint64(v.i))
+	case doubleKind:
+		return protowire.AppendDoubleField(b, 4, v.f)
+	case arrayKind:
+		var array []byte
+		for _, v := range v.array {
+			array = protowire.AppendBytesField(array, 1, v.appendProtobuf(nil))
+		}
+		return protowire.AppendBytesField(b, 5, array)
+	case kvlistKind:
+		var kvlist []byte
+		for _, kv := range v.kvlist {
+			kvlist = protowire.AppendBytesField(kvlist, 1, kv.appendProtobuf(nil))
+		}
+		return protowire.AppendBytesField(b, 6, kvlist)
+	case bytesKind:
+		return protowire.AppendBytesField(b, 7, v.bytes)
+	default:
+		return protowire.AppendStringField(b, 1, v.s)
+	}
+}
+
+// marshalJSON returns the request as an ExportLogsServiceRequest in the
+// OTLP JSON encoding.
+func (r *request) marshalJSON() ([]byte, error) {
+	records := make([]interface{}, len(r.records))
+	for i, rec := range r.records {
+		records[i] = rec.json()
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": jsonAttributes(r.resource),
+				},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]interface{}{"name": ScopeName},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	})
+}
+
+// json returns the record in the OTLP JSON encoding.
+func (r *record) json() map[string]interface{} {
+	m := map[string]interface{}{
+		"timeUnixNano":         strconv.FormatUint(r.time, 10),
+		"observedTimeUnixNano": strconv.FormatUint(r.time, 10),
+		"severityNumber":       r.severity,
+		"severityText":         r.severityText,
+		"body":                 anyValue{kind: stringKind, s: r.body}.json(),
+		"attributes":           jsonAttributes(r.attributes),
+	}
+
+	if r.traceID != nil {
+		m["traceId"] = hex.EncodeToString(r.traceID)
+	}
+
+	if r.spanID != nil {
+		m["spanId"] = hex.EncodeToString(r.spanID)
+	}
+
+	return m
+}
+
+// jsonAttributes returns attributes in the OTLP JSON encoding.
+func jsonAttributes(attrs []keyValue) []interface{} {
+	v := make([]interface{}, len(attrs))
+	for i, kv := range attrs {
+		v[i] = map[string]interface{}{
+			"key":   kv.key,
+			"value": kv.value.json(),
+		}
+	}
+	return v
+}
+
+// json returns the value in the OTLP JSON encoding. Integers are encoded
+// as strings, and non-finite doubles as their names, as in the protobuf
+// JSON mapping.
+func (v anyValue) json() map[string]interface{} {
+	switch v.kind {
+	case boolKind:
+		return map[string]interface{}{"boolValue": v.b}
+	case intKind:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v.i, 10)}
+	case doubleKind:
+		switch {
+		case math.IsNaN(v.f):
+			return map[string]interface{}{"doubleValue": "NaN"}
+		case math.IsInf(v.f, 1):
+			return map[string]interface{}{"doubleValue": "Infinity"}
+		case math.IsInf(v.f, -1):
+			return map[string]interface{}{"doubleValue": "-Infinity"}
+		}
+		return map[string]interface{}{"doubleValue": v.f}
+	case arrayKind:
+		values := make([]interface{}, len(v.array))
+		for i, v := range v.array {
+			values[i] = v.json()
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case kvlistKind:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": jsonAttributes(v.kvlist)}}
+	case bytesKind:
+		return map[string]interface{}{"bytesValue": v.bytes}
+	default:
+		return map[string]interface{}{"stringValue": v.s}
+	}
+}
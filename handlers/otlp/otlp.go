@@ -0,0 +1,229 @@
+// Package otlp implements an OpenTelemetry logs exporter, batching entries
+// and sending them as OTLP log records over HTTP, encoded as protobuf or
+// JSON.
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/batch"
+	"github.com/apex/log/internal/push"
+)
+
+// Format of export requests.
+type Format int
+
+// Formats available.
+const (
+	Protobuf Format = iota
+	JSON
+)
+
+// ScopeName is the instrumentation scope name of exported records.
+const ScopeName = "github.com/apex/log"
+
+// Severity returns the OTLP severity number for a level.
+func Severity(l log.Level) int {
+	switch l {
+	case log.DebugLevel:
+		return 5
+	case log.InfoLevel:
+		return 9
+	case log.WarnLevel:
+		return 13
+	case log.ErrorLevel:
+		return 17
+	default:
+		return 21
+	}
+}
+
+// SeverityText returns the OTLP severity text for a level.
+func SeverityText(l log.Level) string {
+	return strings.ToUpper(l.String())
+}
+
+// Handler implementation.
+type Handler struct {
+	*batch.Handler
+
+	url          string
+	format       Format
+	resource     map[string]interface{}
+	headers      map[string]string
+	client       *http.Client
+	batchOptions []batch.Option
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler exporting to the OTLP/HTTP logs endpoint at `url`, such as
+// "http://localhost:4318/v1/logs".
+func New(url string, options ...Option) *Handler {
+	host, _ := os.Hostname()
+
+	v := &Handler{
+		url: url,
+		resource: map[string]interface{}{
+			"service.name": filepath.Base(os.Args[0]),
+			"host.name":    host,
+		},
+		client: http.DefaultClient,
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	opts := append([]batch.Option{batch.WithName("otlp")}, v.batchOptions...)
+	v.Handler = batch.New(v.export, opts...)
+
+	return v
+}
+
+// WithFormat sets the encoding, defaults to Protobuf.
+func WithFormat(f Format) Option {
+	return func(v *Handler) {
+		v.format = f
+	}
+}
+
+// WithServiceName sets the "service.name" resource attribute, defaults to
+// the program name.
+func WithServiceName(name string) Option {
+	return func(v *Handler) {
+		v.resource["service.name"] = name
+	}
+}
+
+// WithHost sets the "host.name" resource attribute, defaults to os.Hostname().
+func WithHost(name string) Option {
+	return func(v *Handler) {
+		v.resource["host.name"] = name
+	}
+}
+
+// WithResource sets additional resource attributes, such as
+// "service.version" or "deployment.environment".
+func WithResource(attrs map[string]interface{}) Option {
+	return func(v *Handler) {
+		for k, value := range attrs {
+			v.resource[k] = value
+		}
+	}
+}
+
+// WithHeaders sets additional request headers, such as for authentication.
+func WithHeaders(headers map[string]string) Option {
+	return func(v *Handler) {
+		v.headers = headers
+	}
+}
+
+// WithClient sets the HTTP client, defaults to http.DefaultClient.
+func WithClient(c *http.Client) Option {
+	return func(v *Handler) {
+		v.client = c
+	}
+}
+
+// WithBatchOptions sets options of the underlying batch handler.
+func WithBatchOptions(options ...batch.Option) Option {
+	return func(v *Handler) {
+		v.batchOptions = append(v.batchOptions, options...)
+	}
+}
+
+// export sends a batch of entries.
+func (h *Handler) export(ctx context.Context, entries []*log.Entry) error {
+	req := &request{
+		resource: attributes(log.Fields(h.resource)),
+		records:  make([]record, len(entries)),
+	}
+
+	for i, e := range entries {
+		req.records[i] = recordOf(e)
+	}
+
+	var body []byte
+	var contentType string
+
+	switch h.format {
+	case JSON:
+		b, err := req.marshalJSON()
+		if err != nil {
+			return batch.Permanent(err)
+		}
+		body = b
+		contentType = "application/json"
+	default:
+		body = req.marshalProtobuf()
+		contentType = "application/x-protobuf"
+	}
+
+	return push.Post(ctx, h.client, h.url, body, func(r *http.Request) {
+		r.Header.Set("Content-Type", contentType)
+
+		for k, v := range h.headers {
+			r.Header.Set(k, v)
+		}
+	})
+}
+
+// recordOf returns the log record for an entry. The "trace_id" and
+// "span_id" fields, as set by log.TraceparentExtractor, become the
+// record's trace context when valid.
+func recordOf(e *log.Entry) record {
+	r := record{
+		time:         uint64(e.Timestamp.UnixNano()),
+		severity:     Severity(e.Level),
+		severityText: SeverityText(e.Level),
+		body:         e.Message,
+	}
+
+	fields := make(log.Fields, len(e.Fields))
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+
+	if id, ok := decodeID(fields["trace_id"], 16); ok {
+		r.traceID = id
+		delete(fields, "trace_id")
+	}
+
+	if id, ok := decodeID(fields["span_id"], 8); ok {
+		r.spanID = id
+		delete(fields, "span_id")
+	}
+
+	if e.Caller != nil {
+		fields["code.filepath"] = e.Caller.File
+		fields["code.lineno"] = e.Caller.Line
+		fields["code.function"] = e.Caller.Function
+	}
+
+	r.attributes = attributes(fields)
+	return r
+}
+
+// decodeID returns the hex-encoded id `v` of `n` bytes, if valid.
+func decodeID(v interface{}, n int) ([]byte, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) != n*2 {
+		return nil, false
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
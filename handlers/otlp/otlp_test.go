@@ -0,0 +1,209 @@
+package otlp_test
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/batch"
+	"github.com/apex/log/handlers/otlp"
+	"github.com/apex/log/internal/protowire"
+	"github.com/apex/log/internal/push/pushtest"
+)
+
+var timestamp = time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, 5, otlp.Severity(log.DebugLevel))
+	assert.Equal(t, 9, otlp.Severity(log.InfoLevel))
+	assert.Equal(t, 13, otlp.Severity(log.WarnLevel))
+	assert.Equal(t, 17, otlp.Severity(log.ErrorLevel))
+	assert.Equal(t, 21, otlp.Severity(log.FatalLevel))
+	assert.Equal(t, "WARN", otlp.SeverityText(log.WarnLevel))
+}
+
+func TestHandler_json(t *testing.T) {
+	s := pushtest.NewServer(http.StatusOK)
+	defer s.Close()
+
+	h := otlp.New(s.URL+"/v1/logs",
+		otlp.WithFormat(otlp.JSON),
+		otlp.WithServiceName("api"),
+		otlp.WithHost("web-1"),
+		otlp.WithResource(map[string]interface{}{"service.version": "1.2.0"}),
+		otlp.WithHeaders(map[string]string{"Authorization": "Bearer token"}))
+
+	assert.NoError(t, h.HandleLog(&log.Entry{
+		Level:     log.WarnLevel,
+		Message:   "upload failed",
+		Timestamp: timestamp,
+		Caller:    &log.Frame{Function: "main.upload", File: "main.go", Line: 12},
+		Fields: log.Fields{
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+			"user":     "tobi",
+			"size":     1024,
+			"ratio":    0.5,
+			"nan":      math.NaN(),
+			"ok":       true,
+			"error":    errors.New("boom"),
+			"tags":     []string{"a", "b"},
+			"raw":      []byte("hi"),
+			"request":  log.Fields{"method": "GET"},
+			"referrer": (*url.URL)(nil),
+		},
+	}))
+	assert.NoError(t, h.Close())
+
+	assert.Len(t, s.Requests, 1)
+	assert.Equal(t, "/v1/logs", s.Requests[0].URL.Path)
+	assert.Equal(t, "application/json", s.Requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", s.Requests[0].Header.Get("Authorization"))
+
+	assert.JSONEq(t, `{
+		"resourceLogs": [{
+			"resource": {
+				"attributes": [
+					{"key": "host.name", "value": {"stringValue": "web-1"}},
+					{"key": "service.name", "value": {"stringValue": "api"}},
+					{"key": "service.version", "value": {"stringValue": "1.2.0"}}
+				]
+			},
+			"scopeLogs": [{
+				"scope": {"name": "github.com/apex/log"},
+				"logRecords": [{
+					"timeUnixNano": "1577934245000000006",
+					"observedTimeUnixNano": "1577934245000000006",
+					"severityNumber": 13,
+					"severityText": "WARN",
+					"body": {"stringValue": "upload failed"},
+					"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+					"spanId": "00f067aa0ba902b7",
+					"attributes": [
+						{"key": "code.filepath", "value": {"stringValue": "main.go"}},
+						{"key": "code.function", "value": {"stringValue": "main.upload"}},
+						{"key": "code.lineno", "value": {"intValue": "12"}},
+						{"key": "error", "value": {"stringValue": "boom"}},
+						{"key": "nan", "value": {"doubleValue": "NaN"}},
+						{"key": "ok", "value": {"boolValue": true}},
+						{"key": "ratio", "value": {"doubleValue": 0.5}},
+						{"key": "raw", "value": {"bytesValue": "aGk="}},
+						{"key": "referrer", "value": {"stringValue": "<nil>"}},
+						{"key": "request", "value": {"kvlistValue": {"values": [
+							{"key": "method", "value": {"stringValue": "GET"}}
+						]}}},
+						{"key": "size", "value": {"intValue": "1024"}},
+						{"key": "tags", "value": {"arrayValue": {"values": [
+							{"stringValue": "a"},
+							{"stringValue": "b"}
+						]}}},
+						{"key": "user", "value": {"stringValue": "tobi"}}
+					]
+				}]
+			}]
+		}]
+	}`, string(s.Bodies[0]))
+}
+
+// field returns the first field `num` of `fields`.
+func field(t *testing.T, fields []protowire.Field, num int) protowire.Field {
+	for _, f := range fields {
+		if f.Num == num {
+			return f
+		}
+	}
+	t.Fatalf("missing field %d", num)
+	return protowire.Field{}
+}
+
+// decode decodes the message `b`.
+func decode(t *testing.T, b []byte) []protowire.Field {
+	fields, err := protowire.ConsumeFields(b)
+	assert.NoError(t, err)
+	return fields
+}
+
+func TestHandler_protobuf(t *testing.T) {
+	s := pushtest.NewServer(http.StatusOK)
+	defer s.Close()
+
+	h := otlp.New(s.URL, otlp.WithServiceName("api"), otlp.WithHost("web-1"))
+
+	assert.NoError(t, h.HandleLog(&log.Entry{
+		Level:     log.ErrorLevel,
+		Message:   "boom",
+		Timestamp: timestamp,
+		Fields: log.Fields{
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "invalid",
+			"count":    -1,
+		},
+	}))
+	assert.NoError(t, h.Close())
+
+	assert.Equal(t, "application/x-protobuf", s.Requests[0].Header.Get("Content-Type"))
+
+	resourceLogs := decode(t, field(t, decode(t, s.Bodies[0]), 1).Bytes)
+
+	resource := decode(t, field(t, resourceLogs, 1).Bytes)
+	assert.Len(t, resource, 2)
+	kv := decode(t, resource[1].Bytes)
+	assert.Equal(t, "service.name", string(field(t, kv, 1).Bytes))
+	assert.Equal(t, "api", string(field(t, decode(t, field(t, kv, 2).Bytes), 1).Bytes))
+
+	scopeLogs := decode(t, field(t, resourceLogs, 2).Bytes)
+	scope := decode(t, field(t, scopeLogs, 1).Bytes)
+	assert.Equal(t, otlp.ScopeName, string(field(t, scope, 1).Bytes))
+
+	rec := decode(t, field(t, scopeLogs, 2).Bytes)
+	assert.Equal(t, uint64(timestamp.UnixNano()), field(t, rec, 1).Value)
+	assert.Equal(t, uint64(timestamp.UnixNano()), field(t, rec, 11).Value)
+	assert.Equal(t, uint64(17), field(t, rec, 2).Value)
+	assert.Equal(t, "ERROR", string(field(t, rec, 3).Bytes))
+	assert.Equal(t, "boom", string(field(t, decode(t, field(t, rec, 5).Bytes), 1).Bytes))
+	assert.Equal(t, []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}, field(t, rec, 9).Bytes)
+
+	var attrs []string
+	for _, f := range rec {
+		if f.Num == 6 {
+			kv := decode(t, f.Bytes)
+			attrs = append(attrs, string(field(t, kv, 1).Bytes))
+
+			if string(field(t, kv, 1).Bytes) == "count" {
+				assert.Equal(t, uint64(math.MaxUint64), field(t, decode(t, field(t, kv, 2).Bytes), 3).Value)
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"count", "span_id"}, attrs)
+}
+
+func TestHandler_retry(t *testing.T) {
+	s := pushtest.NewServer(http.StatusOK, http.StatusServiceUnavailable)
+	defer s.Close()
+
+	h := otlp.New(s.URL, otlp.WithBatchOptions(batch.WithRetries(3, time.Millisecond)))
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "hello"}))
+	assert.NoError(t, h.Close())
+	assert.Len(t, s.Requests, 2)
+}
+
+func TestHandler_permanent(t *testing.T) {
+	s := pushtest.NewServer(http.StatusOK, http.StatusBadRequest)
+	defer s.Close()
+
+	h := otlp.New(s.URL, otlp.WithBatchOptions(
+		batch.WithRetries(3, time.Millisecond),
+		batch.WithErrorHandler(func(error) {})))
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "hello"}))
+	assert.EqualError(t, h.Close(), "otlp: 400 Bad Request: error details")
+	assert.Len(t, s.Requests, 1)
+}
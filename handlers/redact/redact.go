@@ -0,0 +1,283 @@
+// Package redact implements a handler which redacts secrets and personal
+// information from entries before passing them to another handler.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Mask is the value used in place of redacted values.
+const Mask = "[REDACTED]"
+
+// DefaultKeys is a list of commonly sensitive field names.
+var DefaultKeys = []string{
+	"authorization",
+	"cookie",
+	"password",
+	"secret",
+	"token",
+	"api_key",
+	"apikey",
+}
+
+// Pattern matches sensitive values.
+type Pattern struct {
+	// Name of the pattern.
+	Name string
+
+	// Regexp matching candidate values.
+	Regexp *regexp.Regexp
+
+	// Valid optionally verifies a match, reducing false positives.
+	Valid func(string) bool
+}
+
+// Value patterns.
+var (
+	JWT = Pattern{
+		Name:   "jwt",
+		Regexp: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	}
+
+	CreditCard = Pattern{
+		Name:   "credit_card",
+		Regexp: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:  luhn,
+	}
+
+	Email = Pattern{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+
+	AWSAccessKey = Pattern{
+		Name:   "aws_access_key",
+		Regexp: regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+	}
+)
+
+// DefaultPatterns is a list of all built-in value patterns.
+var DefaultPatterns = []Pattern{
+	JWT,
+	CreditCard,
+	Email,
+	AWSAccessKey,
+}
+
+// Handler implementation.
+type Handler struct {
+	Handler log.Handler
+
+	keys     map[string]bool
+	globs    []string
+	regexps  []*regexp.Regexp
+	patterns []Pattern
+	hash     bool
+	salt     []byte
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler redacting entries passed to `h`.
+func New(h log.Handler, options ...Option) *Handler {
+	v := &Handler{
+		Handler: h,
+		keys:    map[string]bool{},
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	return v
+}
+
+// WithKeys redacts fields by name, case-insensitively.
+func WithKeys(names ...string) Option {
+	return func(v *Handler) {
+		for _, name := range names {
+			v.keys[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WithKeyGlobs redacts fields with names matching glob patterns such as
+// "*_token", using the syntax of path.Match, case-insensitively.
+func WithKeyGlobs(patterns ...string) Option {
+	return func(v *Handler) {
+		for _, p := range patterns {
+			v.globs = append(v.globs, strings.ToLower(p))
+		}
+	}
+}
+
+// WithKeyRegexps redacts fields with names matching regular expressions.
+func WithKeyRegexps(regexps ...*regexp.Regexp) Option {
+	return func(v *Handler) {
+		v.regexps = append(v.regexps, regexps...)
+	}
+}
+
+// WithPatterns redacts matches of value patterns in string fields
+// and the message.
+func WithPatterns(patterns ...Pattern) Option {
+	return func(v *Handler) {
+		v.patterns = append(v.patterns, patterns...)
+	}
+}
+
+// WithHash replaces redacted values with a keyed hash instead of a mask.
+// The same value and salt always produce the same hash, so redacted values
+// may still be correlated across entries.
+func WithHash(salt []byte) Option {
+	return func(v *Handler) {
+		v.hash = true
+		v.salt = salt
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	c := *e
+	c.Message = h.redactString(e.Message)
+	c.Fields = h.redactFields(e.Fields)
+	return h.Handler.HandleLog(&c)
+}
+
+// redactFields returns a redacted copy of `fields`.
+func (h *Handler) redactFields(fields log.Fields) log.Fields {
+	if fields == nil {
+		return nil
+	}
+
+	f := make(log.Fields, len(fields))
+
+	for k, v := range fields {
+		if h.matchKey(k) {
+			f[k] = h.replace(fmt.Sprint(v))
+			continue
+		}
+
+		f[k] = h.redactValue(v)
+	}
+
+	return f
+}
+
+// redactValue returns a redacted copy of `v`.
+func (h *Handler) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return h.redactString(v)
+	case log.Fields:
+		return h.redactFields(v)
+	case map[string]interface{}:
+		return map[string]interface{}(h.redactFields(v))
+	case []string:
+		s := make([]string, len(v))
+		for i, v := range v {
+			s[i] = h.redactString(v)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, v := range v {
+			s[i] = h.redactValue(v)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// redactString returns `s` with all pattern matches replaced.
+func (h *Handler) redactString(s string) string {
+	for _, p := range h.patterns {
+		s = p.Regexp.ReplaceAllStringFunc(s, func(m string) string {
+			if p.Valid != nil && !p.Valid(m) {
+				return m
+			}
+			return h.replace(m)
+		})
+	}
+
+	return s
+}
+
+// matchKey returns true if the field name `k` is sensitive.
+func (h *Handler) matchKey(k string) bool {
+	lower := strings.ToLower(k)
+
+	if h.keys[lower] {
+		return true
+	}
+
+	for _, p := range h.globs {
+		if ok, _ := path.Match(p, lower); ok {
+			return true
+		}
+	}
+
+	for _, re := range h.regexps {
+		if re.MatchString(k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replace returns the replacement for sensitive value `s`.
+func (h *Handler) replace(s string) string {
+	if !h.hash {
+		return Mask
+	}
+
+	m := hmac.New(sha256.New, h.salt)
+	m.Write([]byte(s))
+	return "sha256:" + hex.EncodeToString(m.Sum(nil))[:16]
+}
+
+// luhn returns true if the digits in `s` pass the Luhn checksum.
+func luhn(s string) bool {
+	var sum, n int
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		n++
+	}
+
+	return n >= 13 && sum%10 == 0
+}
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	return log.FlushHandler(h.Handler)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	return log.CloseHandler(h.Handler)
+}
@@ -0,0 +1,300 @@
+// Package retry implements a handler which retries failed entries with
+// exponential backoff and jitter. After consecutive failures the circuit
+// opens and entries are passed to an optional fallback handler instead,
+// until a cool-down has elapsed and a probe entry succeeds.
+//
+// Retries block the goroutine logging, consider wrapping this handler
+// with the async handler.
+package retry
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// ErrOpen is returned when the circuit is open and there is no fallback handler.
+var ErrOpen = errors.New("circuit open")
+
+// Clock provides the time, allowing it to be controlled in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+}
+
+// clock implementation.
+type clock struct{}
+
+// Now implementation.
+func (clock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep implementation.
+func (clock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// State of the circuit.
+type State int
+
+// States.
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+// stateNames mapping.
+var stateNames = [...]string{
+	Closed:   "closed",
+	Open:     "open",
+	HalfOpen: "half-open",
+}
+
+// String implementation.
+func (s State) String() string {
+	return stateNames[s]
+}
+
+// Handler implementation.
+type Handler struct {
+	Handler log.Handler
+
+	attempts  int
+	min       time.Duration
+	max       time.Duration
+	threshold int
+	cooldown  time.Duration
+	fallback  log.Handler
+	retryable func(error) bool
+	clock     Clock
+	random    func() float64
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler retrying entries passed to `h`.
+func New(h log.Handler, options ...Option) *Handler {
+	v := &Handler{
+		Handler:   h,
+		attempts:  3,
+		min:       100 * time.Millisecond,
+		max:       5 * time.Second,
+		threshold: 5,
+		cooldown:  30 * time.Second,
+		retryable: func(error) bool { return true },
+		clock:     clock{},
+		random:    rand.Float64,
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	return v
+}
+
+// WithAttempts sets the maximum number of attempts for each entry (default: 3).
+func WithAttempts(n int) Option {
+	return func(v *Handler) {
+		v.attempts = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubling for each
+// retry up to `max` (default: 100ms, 5s). Half to all of the delay is used
+// at random to avoid retrying in lockstep.
+func WithBackoff(min, max time.Duration) Option {
+	return func(v *Handler) {
+		v.min = min
+		v.max = max
+	}
+}
+
+// WithThreshold sets the number of consecutive failed entries which
+// opens the circuit (default: 5).
+func WithThreshold(n int) Option {
+	return func(v *Handler) {
+		v.threshold = n
+	}
+}
+
+// WithCooldown sets how long the circuit stays open before an entry is
+// passed to the handler to probe for recovery (default: 30s).
+func WithCooldown(d time.Duration) Option {
+	return func(v *Handler) {
+		v.cooldown = d
+	}
+}
+
+// WithFallback sets the handler used while the circuit is open, and for
+// entries which failed all attempts.
+func WithFallback(h log.Handler) Option {
+	return func(v *Handler) {
+		v.fallback = h
+	}
+}
+
+// WithRetryable sets the function deciding which errors are transient and
+// retried, by default all errors are.
+func WithRetryable(fn func(error) bool) Option {
+	return func(v *Handler) {
+		v.retryable = fn
+	}
+}
+
+// WithClock sets the clock used for the cool-down and backoff.
+func WithClock(c Clock) Option {
+	return func(v *Handler) {
+		v.clock = c
+	}
+}
+
+// WithRandom sets the function returning a random number in [0.0,1.0)
+// used for jitter.
+func WithRandom(fn func() float64) Option {
+	return func(v *Handler) {
+		v.random = fn
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	if !h.allow() {
+		return h.handleFallback(e, ErrOpen)
+	}
+
+	err := h.try(e)
+	h.record(err)
+
+	if err != nil {
+		return h.handleFallback(e, err)
+	}
+
+	return nil
+}
+
+// State returns the state of the circuit.
+func (h *Handler) State() State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	err := log.FlushHandler(h.Handler)
+
+	if h.fallback != nil {
+		if e := log.FlushHandler(h.fallback); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	err := log.CloseHandler(h.Handler)
+
+	if h.fallback != nil {
+		if e := log.CloseHandler(h.fallback); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// allow returns true if the entry should be passed to the handler.
+func (h *Handler) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.state {
+	case Open:
+		if h.clock.Now().Sub(h.openedAt) < h.cooldown {
+			return false
+		}
+		h.state = HalfOpen
+		h.probing = true
+		return true
+	case HalfOpen:
+		if h.probing {
+			return false
+		}
+		h.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record the outcome of an entry, opening or closing the circuit.
+func (h *Handler) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.probing = false
+
+	if err == nil {
+		h.state = Closed
+		h.failures = 0
+		return
+	}
+
+	h.failures++
+
+	if h.state == HalfOpen || h.failures >= h.threshold {
+		h.state = Open
+		h.openedAt = h.clock.Now()
+	}
+}
+
+// try passing the entry to the handler until an attempt succeeds.
+func (h *Handler) try(e *log.Entry) error {
+	for attempt := 1; ; attempt++ {
+		err := h.Handler.HandleLog(e)
+
+		if err == nil || attempt >= h.attempts || !h.retryable(err) {
+			return err
+		}
+
+		h.clock.Sleep(h.delay(attempt))
+	}
+}
+
+// delay returns the backoff delay after the given attempt.
+func (h *Handler) delay(attempt int) time.Duration {
+	d := h.min
+	for i := 1; i < attempt && d < h.max; i++ {
+		d *= 2
+	}
+
+	if d > h.max {
+		d = h.max
+	}
+
+	return d/2 + time.Duration(h.random()*float64(d/2))
+}
+
+// handleFallback passes the entry to the fallback handler, or returns `err`.
+func (h *Handler) handleFallback(e *log.Entry, err error) error {
+	if h.fallback == nil {
+		return err
+	}
+
+	return h.fallback.HandleLog(e)
+}
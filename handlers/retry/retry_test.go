@@ -0,0 +1,127 @@
+package retry_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/retry"
+)
+
+// clock implementation.
+type clock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+// flaky handler failing while down.
+type flaky struct {
+	memory.Handler
+	down  bool
+	calls int
+}
+
+func (f *flaky) HandleLog(e *log.Entry) error {
+	f.calls++
+	if f.down {
+		return errors.New("unavailable")
+	}
+	return f.Handler.HandleLog(e)
+}
+
+func TestRetry(t *testing.T) {
+	c := &clock{}
+	f := &flaky{down: true}
+
+	h := retry.New(f,
+		retry.WithClock(c),
+		retry.WithAttempts(4),
+		retry.WithBackoff(time.Second, 3*time.Second),
+		retry.WithRandom(func() float64 { return 0.5 }))
+
+	err := h.HandleLog(&log.Entry{Message: "upload"})
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, 4, f.calls)
+	assert.Equal(t, []time.Duration{
+		750 * time.Millisecond,
+		1500 * time.Millisecond,
+		2250 * time.Millisecond,
+	}, c.sleeps)
+}
+
+func TestRetry_retryable(t *testing.T) {
+	f := &flaky{down: true}
+
+	h := retry.New(f,
+		retry.WithClock(&clock{}),
+		retry.WithRetryable(func(error) bool { return false }))
+
+	assert.Error(t, h.HandleLog(&log.Entry{}))
+	assert.Equal(t, 1, f.calls)
+}
+
+func TestCircuit(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	f := &flaky{down: true}
+	fallback := memory.New()
+
+	h := retry.New(f,
+		retry.WithClock(c),
+		retry.WithAttempts(1),
+		retry.WithThreshold(2),
+		retry.WithCooldown(time.Minute),
+		retry.WithFallback(fallback))
+
+	// failures are passed to the fallback until the circuit opens
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "a"}))
+	assert.Equal(t, retry.Closed, h.State())
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "b"}))
+	assert.Equal(t, retry.Open, h.State())
+
+	// open, the handler is not called
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "c"}))
+	assert.Equal(t, 2, f.calls)
+	assert.Len(t, fallback.Entries, 3)
+
+	// half-open, probe fails
+	c.now = c.now.Add(time.Minute)
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "d"}))
+	assert.Equal(t, 3, f.calls)
+	assert.Equal(t, retry.Open, h.State())
+
+	// half-open, probe succeeds
+	f.down = false
+	c.now = c.now.Add(time.Minute)
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "e"}))
+	assert.Equal(t, retry.Closed, h.State())
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "f"}))
+
+	assert.Len(t, fallback.Entries, 4)
+	assert.Len(t, f.Entries, 2)
+}
+
+func TestCircuit_noFallback(t *testing.T) {
+	f := &flaky{down: true}
+
+	h := retry.New(f,
+		retry.WithClock(&clock{}),
+		retry.WithAttempts(1),
+		retry.WithThreshold(1))
+
+	assert.EqualError(t, h.HandleLog(&log.Entry{}), "unavailable")
+	assert.Equal(t, retry.ErrOpen, h.HandleLog(&log.Entry{}))
+	assert.Equal(t, "open", h.State().String())
+}
@@ -0,0 +1,170 @@
+// Package router implements a handler which dispatches entries to
+// handlers based on ordered routes.
+package router
+
+import (
+	"reflect"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/filter"
+	"github.com/apex/log/handlers/multi"
+	"github.com/apex/log/internal/safe"
+)
+
+// Route passes entries matching Match to Handlers. A nil Match
+// matches every entry. Routing stops at the first matching route
+// unless Continue is true.
+type Route struct {
+	Match    filter.Predicate
+	Handlers []log.Handler
+	Continue bool
+}
+
+// Match returns a route passing entries matching `p` to `h`.
+func Match(p filter.Predicate, h ...log.Handler) Route {
+	return Route{
+		Match:    p,
+		Handlers: h,
+	}
+}
+
+// Handler implementation.
+//
+// Routes are evaluated in order, entries matching no route are passed to
+// Default. A handler present in several matching routes receives the entry
+// once. Every selected handler is invoked even when others fail, failures
+// are returned as a *multi.Error.
+type Handler struct {
+	Routes  []Route
+	Default []log.Handler
+}
+
+// New handler.
+func New(routes ...Route) *Handler {
+	return &Handler{
+		Routes: routes,
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	handlers := h.route(e)
+	errs := make([]error, len(handlers))
+
+	for i, handler := range handlers {
+		errs[i] = safe.HandleLog(handler, e)
+	}
+
+	return errorOf(handlers, errs, 1)
+}
+
+// Flush implements log.Flusher, flushing all handlers.
+func (h *Handler) Flush() error {
+	handlers := h.handlers()
+	errs := make([]error, len(handlers))
+
+	for i, handler := range handlers {
+		errs[i] = log.FlushHandler(handler)
+	}
+
+	return errorOf(handlers, errs, 0)
+}
+
+// Close implements log.Closer, closing all handlers.
+func (h *Handler) Close() error {
+	handlers := h.handlers()
+	errs := make([]error, len(handlers))
+
+	for i, handler := range handlers {
+		errs[i] = log.CloseHandler(handler)
+	}
+
+	return errorOf(handlers, errs, 0)
+}
+
+// route returns the handlers selected for `e`.
+func (h *Handler) route(e *log.Entry) []log.Handler {
+	var s set
+	var matched bool
+
+	for _, r := range h.Routes {
+		if r.Match != nil && !r.Match(e) {
+			continue
+		}
+
+		matched = true
+		s.add(r.Handlers...)
+
+		if !r.Continue {
+			break
+		}
+	}
+
+	if !matched {
+		s.add(h.Default...)
+	}
+
+	return s.handlers
+}
+
+// handlers returns all handlers of the routes and default.
+func (h *Handler) handlers() []log.Handler {
+	var s set
+
+	for _, r := range h.Routes {
+		s.add(r.Handlers...)
+	}
+
+	s.add(h.Default...)
+	return s.handlers
+}
+
+// set of handlers, in the order added. Handlers which are not comparable,
+// such as log.HandlerFunc, are never considered duplicates.
+type set struct {
+	handlers []log.Handler
+	seen     map[log.Handler]bool
+}
+
+// add handlers not yet in the set.
+func (s *set) add(handlers ...log.Handler) {
+	for _, h := range handlers {
+		if h == nil {
+			continue
+		}
+
+		if !reflect.TypeOf(h).Comparable() {
+			s.handlers = append(s.handlers, h)
+			continue
+		}
+
+		if s.seen[h] {
+			continue
+		}
+
+		if s.seen == nil {
+			s.seen = make(map[log.Handler]bool)
+		}
+
+		s.seen[h] = true
+		s.handlers = append(s.handlers, h)
+	}
+}
+
+// errorOf returns a *multi.Error for the non-nil errors in `errs`, returned
+// by the handler at the same index in `handlers` losing `entries`, or nil.
+func errorOf(handlers []log.Handler, errs []error, entries int) error {
+	var v []error
+
+	for i, err := range errs {
+		if err != nil {
+			v = append(v, log.NewHandlerError(handlers[i], err, entries))
+		}
+	}
+
+	if len(v) == 0 {
+		return nil
+	}
+
+	return &multi.Error{Errors: v}
+}
@@ -0,0 +1,121 @@
+package router_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/filter"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/multi"
+	"github.com/apex/log/handlers/router"
+)
+
+func Test(t *testing.T) {
+	audit := memory.New()
+	errs := memory.New()
+	stdout := memory.New()
+
+	h := router.New(
+		router.Match(filter.HasField("audit"), audit),
+		router.Route{
+			Match:    filter.MinLevel(log.ErrorLevel),
+			Handlers: []log.Handler{errs},
+			Continue: true,
+		},
+		router.Match(filter.MustParse(`level == fatal`), stdout),
+	)
+	h.Default = []log.Handler{stdout}
+
+	ctx := log.Logger{
+		Handler: h,
+		Level:   log.DebugLevel,
+	}
+
+	ctx.WithField("audit", true).Error("login")
+	ctx.Error("boom")
+	ctx.Info("hello")
+
+	assert.Len(t, audit.Entries, 1)
+	assert.Equal(t, "login", audit.Entries[0].Message)
+
+	assert.Len(t, errs.Entries, 1)
+	assert.Equal(t, "boom", errs.Entries[0].Message)
+
+	assert.Len(t, stdout.Entries, 1)
+	assert.Equal(t, "hello", stdout.Entries[0].Message)
+}
+
+func TestHandler_continue(t *testing.T) {
+	a := memory.New()
+	b := memory.New()
+
+	h := router.New(
+		router.Route{Match: filter.Logger("db"), Handlers: []log.Handler{a}, Continue: true},
+		router.Route{Handlers: []log.Handler{a, b}},
+	)
+
+	l := &log.Logger{Handler: h, Level: log.InfoLevel}
+	l.Named("db").Info("query")
+
+	assert.Len(t, a.Entries, 1)
+	assert.Len(t, b.Entries, 1)
+}
+
+type failing struct{}
+
+func (failing) HandleLog(*log.Entry) error {
+	return errors.New("unavailable")
+}
+
+type closer struct {
+	*memory.Handler
+	closed int
+}
+
+func (c *closer) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestHandler_errors(t *testing.T) {
+	a := memory.New()
+
+	h := router.New(router.Match(nil, failing{}, a))
+
+	err := h.HandleLog(&log.Entry{Message: "hello"})
+	assert.EqualError(t, err, "router_test.failing: unavailable")
+
+	var e *multi.Error
+	assert.True(t, errors.As(err, &e))
+	assert.Len(t, a.Entries, 1)
+}
+
+func TestHandler_panic(t *testing.T) {
+	a := memory.New()
+	panicking := log.HandlerFunc(func(*log.Entry) error {
+		panic("boom")
+	})
+
+	h := router.New(router.Match(nil, panicking, a))
+
+	err := h.HandleLog(&log.Entry{Message: "hello"})
+	assert.EqualError(t, err, "log.HandlerFunc: panic: boom")
+	assert.Len(t, a.Entries, 1)
+}
+
+func TestHandler_Close(t *testing.T) {
+	c := &closer{Handler: memory.New()}
+
+	h := router.New(
+		router.Match(filter.HasField("audit"), c),
+		router.Match(nil, c),
+	)
+	h.Default = []log.Handler{c}
+
+	assert.NoError(t, h.Flush())
+	assert.NoError(t, h.Close())
+	assert.Equal(t, 1, c.closed)
+}
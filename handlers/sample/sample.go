@@ -0,0 +1,232 @@
+// Package sample implements a sampling handler, useful for reducing the
+// volume of high-traffic log entries passed to another handler.
+package sample
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// random returns a pseudo-random number in [0.0,1.0).
+var random = rand.Float64
+
+// Sampler decides which entries are kept.
+type Sampler interface {
+	Sample(*log.Entry) bool
+}
+
+// The SamplerFunc type is an adapter to allow the use of ordinary functions
+// as samplers.
+type SamplerFunc func(*log.Entry) bool
+
+// Sample calls f(e).
+func (f SamplerFunc) Sample(e *log.Entry) bool {
+	return f(e)
+}
+
+// counters is the number of counters used by Burst, entries are assigned
+// to a counter by hashing their level and message.
+const counters = 4096
+
+// counter of entries seen in an interval.
+type counter struct {
+	resetAt int64
+	count   uint64
+}
+
+// inc increments the counter, resetting it when the interval has elapsed.
+func (c *counter) inc(t time.Time, interval time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+
+	if resetAt > now {
+		return atomic.AddUint64(&c.count, 1)
+	}
+
+	atomic.StoreUint64(&c.count, 1)
+
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+interval.Nanoseconds()) {
+		return atomic.AddUint64(&c.count, 1)
+	}
+
+	return 1
+}
+
+// burst sampler.
+type burst struct {
+	first      uint64
+	thereafter uint64
+	interval   time.Duration
+	counts     [counters]counter
+}
+
+// Burst returns a sampler which keeps the first `first` entries with the
+// same level and message in each `interval`, and every `thereafter`th
+// entry after that. When `thereafter` is zero all further entries in the
+// interval are dropped.
+//
+// The entry timestamp is used to determine the interval, so entries are
+// sampled consistently with the time they were logged.
+func Burst(first, thereafter int, interval time.Duration) Sampler {
+	return &burst{
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		interval:   interval,
+	}
+}
+
+// Sample implements Sampler.
+func (s *burst) Sample(e *log.Entry) bool {
+	t := e.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	n := s.counts[key(e)%counters].inc(t, s.interval)
+
+	if n <= s.first {
+		return true
+	}
+
+	if s.thereafter == 0 {
+		return false
+	}
+
+	return (n-s.first)%s.thereafter == 0
+}
+
+// key returns a hash of the entry level and message.
+func key(e *log.Entry) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte{byte(e.Level)})
+	h.Write([]byte(e.Message))
+	return h.Sum32()
+}
+
+// Rate returns a sampler which keeps entries with the probability `rate`,
+// from 0 (none) to 1 (all).
+func Rate(rate float64) Sampler {
+	return SamplerFunc(func(e *log.Entry) bool {
+		return random() < rate
+	})
+}
+
+// Stats for the handler.
+type Stats struct {
+	Passed         uint64
+	Dropped        uint64
+	DroppedByLevel map[log.Level]uint64
+}
+
+// Handler implementation.
+type Handler struct {
+	Handler log.Handler
+
+	sampler Sampler
+	levels  map[log.Level]Sampler
+	exempt  map[log.Level]bool
+
+	passed  uint64
+	dropped [log.FatalLevel + 1]uint64
+}
+
+// Option function.
+type Option func(*Handler)
+
+// New handler passing entries kept by the sampler `s` to `h`. By default
+// error and fatal entries are exempt from sampling.
+func New(h log.Handler, s Sampler, options ...Option) *Handler {
+	v := &Handler{
+		Handler: h,
+		sampler: s,
+		levels:  map[log.Level]Sampler{},
+		exempt: map[log.Level]bool{
+			log.ErrorLevel: true,
+			log.FatalLevel: true,
+		},
+	}
+
+	for _, o := range options {
+		o(v)
+	}
+
+	return v
+}
+
+// WithLevel sets the sampler used for entries of the given level,
+// overriding the default sampler.
+func WithLevel(level log.Level, s Sampler) Option {
+	return func(v *Handler) {
+		v.levels[level] = s
+	}
+}
+
+// WithExempt sets the levels which are never sampled, replacing the
+// default of error and fatal.
+func WithExempt(levels ...log.Level) Option {
+	return func(v *Handler) {
+		v.exempt = map[log.Level]bool{}
+		for _, l := range levels {
+			v.exempt[l] = true
+		}
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	if !h.sample(e) {
+		if e.Level >= 0 && int(e.Level) < len(h.dropped) {
+			atomic.AddUint64(&h.dropped[e.Level], 1)
+		}
+		return nil
+	}
+
+	atomic.AddUint64(&h.passed, 1)
+	return h.Handler.HandleLog(e)
+}
+
+// Stats returns the number of entries passed and dropped.
+func (h *Handler) Stats() Stats {
+	s := Stats{
+		Passed:         atomic.LoadUint64(&h.passed),
+		DroppedByLevel: map[log.Level]uint64{},
+	}
+
+	for l := range h.dropped {
+		n := atomic.LoadUint64(&h.dropped[l])
+		if n == 0 {
+			continue
+		}
+		s.Dropped += n
+		s.DroppedByLevel[log.Level(l)] = n
+	}
+
+	return s
+}
+
+// sample returns true if the entry should be kept.
+func (h *Handler) sample(e *log.Entry) bool {
+	if h.exempt[e.Level] {
+		return true
+	}
+
+	if s, ok := h.levels[e.Level]; ok {
+		return s.Sample(e)
+	}
+
+	return h.sampler.Sample(e)
+}
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	return log.FlushHandler(h.Handler)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	return log.CloseHandler(h.Handler)
+}
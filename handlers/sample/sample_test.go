@@ -0,0 +1,88 @@
+package sample
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+)
+
+func Test(t *testing.T) {
+	h := memory.New()
+	s := New(h, Burst(2, 3, time.Second))
+
+	now := time.Unix(0, 0)
+	log.Now = func() time.Time {
+		return now
+	}
+
+	l := log.Logger{
+		Handler: s,
+		Level:   log.DebugLevel,
+	}
+
+	for i := 0; i < 10; i++ {
+		l.Info("upload")
+		l.Debug("uploading")
+		l.Error("boom")
+	}
+
+	now = now.Add(time.Second)
+	l.Info("upload")
+
+	var messages []string
+	for _, e := range h.Entries {
+		if e.Level == log.InfoLevel {
+			messages = append(messages, e.Message)
+		}
+	}
+
+	// first two, then the 5th and 8th, then the first of the next interval
+	assert.Len(t, messages, 5)
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(4+4+10+1), stats.Passed)
+	assert.Equal(t, uint64(12), stats.Dropped)
+	assert.Equal(t, map[log.Level]uint64{
+		log.DebugLevel: 6,
+		log.InfoLevel:  6,
+	}, stats.DroppedByLevel)
+}
+
+func TestRate(t *testing.T) {
+	values := []float64{0.1, 0.5, 0.9, 0.2}
+	random = func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+
+	h := memory.New()
+	s := New(h, Rate(0.25), WithExempt())
+
+	for i := 0; i < 4; i++ {
+		s.HandleLog(&log.Entry{Level: log.ErrorLevel, Message: "boom"})
+	}
+
+	assert.Len(t, h.Entries, 2)
+	assert.Equal(t, uint64(2), s.Stats().Dropped)
+}
+
+func TestWithLevel(t *testing.T) {
+	none := SamplerFunc(func(*log.Entry) bool { return false })
+	all := SamplerFunc(func(*log.Entry) bool { return true })
+
+	h := memory.New()
+	s := New(h, none, WithLevel(log.WarnLevel, all))
+
+	s.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "upload"})
+	s.HandleLog(&log.Entry{Level: log.WarnLevel, Message: "retry"})
+	s.HandleLog(&log.Entry{Level: log.FatalLevel, Message: "boom"})
+
+	assert.Len(t, h.Entries, 2)
+	assert.Equal(t, "retry", h.Entries[0].Message)
+	assert.Equal(t, "boom", h.Entries[1].Message)
+}
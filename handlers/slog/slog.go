@@ -0,0 +1,256 @@
+//go:build go1.21
+// +build go1.21
+
+// Package slog implements a bridge between apex/log and the standard
+// library's log/slog package. Handler forwards entries to any slog.Handler,
+// and Adapter exposes any log.Handler as a slog.Handler.
+package slog
+
+import (
+	"context"
+	"runtime"
+
+	stdslog "log/slog"
+
+	"github.com/apex/log"
+)
+
+// LevelFatal is the slog level used for log.FatalLevel, as slog has no
+// equivalent of its own.
+const LevelFatal = stdslog.LevelError + 4
+
+// Handler implementation.
+type Handler struct {
+	Handler stdslog.Handler
+}
+
+// New handler forwarding entries to the slog handler `h`.
+func New(h stdslog.Handler) *Handler {
+	return &Handler{
+		Handler: h,
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	ctx := context.Background()
+	level := FromLevel(e.Level)
+
+	if !h.Handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	r := stdslog.NewRecord(e.Timestamp, level, e.Message, 0)
+
+	for _, name := range e.Fields.Names() {
+		r.AddAttrs(attr(name, e.Fields.Get(name)))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// attr returns an attribute for the given field, nested fields become groups.
+func attr(name string, v interface{}) stdslog.Attr {
+	var fields log.Fields
+
+	switch v := v.(type) {
+	case log.Fields:
+		fields = v
+	case map[string]interface{}:
+		fields = log.Fields(v)
+	default:
+		return stdslog.Any(name, v)
+	}
+
+	var attrs []stdslog.Attr
+	for _, k := range fields.Names() {
+		attrs = append(attrs, attr(k, fields.Get(k)))
+	}
+
+	return stdslog.Attr{Key: name, Value: stdslog.GroupValue(attrs...)}
+}
+
+// Adapter exposes a log.Handler as a slog.Handler. Groups are represented
+// as nested log.Fields.
+type Adapter struct {
+	handler log.Handler
+	level   stdslog.Leveler
+	attrs   []groupAttrs
+	groups  []string
+}
+
+// groupAttrs is a set of attributes added with WithAttrs, qualified by the
+// groups open at the time.
+type groupAttrs struct {
+	groups []string
+	attrs  []stdslog.Attr
+}
+
+// assert interface compliance.
+var _ stdslog.Handler = (*Adapter)(nil)
+
+// NewAdapter returns a slog.Handler passing records to `h` when they meet
+// the minimum `level`. When `level` is nil all records are handled.
+func NewAdapter(h log.Handler, level stdslog.Leveler) *Adapter {
+	return &Adapter{
+		handler: h,
+		level:   level,
+	}
+}
+
+// Enabled implements slog.Handler.
+func (a *Adapter) Enabled(ctx context.Context, level stdslog.Level) bool {
+	if a.level == nil {
+		return true
+	}
+
+	return level >= a.level.Level()
+}
+
+// Handle implements slog.Handler.
+func (a *Adapter) Handle(ctx context.Context, r stdslog.Record) error {
+	fields := group{}
+
+	for _, v := range a.attrs {
+		for _, attr := range v.attrs {
+			addAttr(fields, v.groups, attr)
+		}
+	}
+
+	r.Attrs(func(attr stdslog.Attr) bool {
+		addAttr(fields, a.groups, attr)
+		return true
+	})
+
+	e := &log.Entry{
+		Fields:    fields.Fields(),
+		Level:     ToLevel(r.Level),
+		Timestamp: r.Time,
+		Message:   r.Message,
+	}
+
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.Caller = &log.Frame{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+		}
+	}
+
+	return a.handler.HandleLog(e)
+}
+
+// WithAttrs implements slog.Handler.
+func (a *Adapter) WithAttrs(attrs []stdslog.Attr) stdslog.Handler {
+	if len(attrs) == 0 {
+		return a
+	}
+
+	v := *a
+	v.attrs = append(a.attrs[:len(a.attrs):len(a.attrs)], groupAttrs{
+		groups: a.groups,
+		attrs:  attrs,
+	})
+
+	return &v
+}
+
+// WithGroup implements slog.Handler.
+func (a *Adapter) WithGroup(name string) stdslog.Handler {
+	if name == "" {
+		return a
+	}
+
+	v := *a
+	v.groups = append(a.groups[:len(a.groups):len(a.groups)], name)
+	return &v
+}
+
+// group of attributes.
+type group map[string]interface{}
+
+// Fields returns the group as fields, including nested groups.
+func (g group) Fields() log.Fields {
+	f := log.Fields{}
+
+	for k, v := range g {
+		if v, ok := v.(group); ok {
+			f[k] = v.Fields()
+			continue
+		}
+		f[k] = v
+	}
+
+	return f
+}
+
+// addAttr adds `attr` to `fields`, nested under `groups`.
+func addAttr(fields group, groups []string, attr stdslog.Attr) {
+	attr.Value = attr.Value.Resolve()
+
+	if attr.Equal(stdslog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == stdslog.KindGroup {
+		attrs := attr.Value.Group()
+
+		if len(attrs) == 0 {
+			return
+		}
+
+		if attr.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], attr.Key)
+		}
+
+		for _, v := range attrs {
+			addAttr(fields, groups, v)
+		}
+
+		return
+	}
+
+	for _, name := range groups {
+		g, ok := fields[name].(group)
+		if !ok {
+			g = group{}
+			fields[name] = g
+		}
+		fields = g
+	}
+
+	fields[attr.Key] = attr.Value.Any()
+}
+
+// ToLevel returns the log.Level for a slog level.
+func ToLevel(l stdslog.Level) log.Level {
+	switch {
+	case l < stdslog.LevelInfo:
+		return log.DebugLevel
+	case l < stdslog.LevelWarn:
+		return log.InfoLevel
+	case l < stdslog.LevelError:
+		return log.WarnLevel
+	case l < LevelFatal:
+		return log.ErrorLevel
+	default:
+		return log.FatalLevel
+	}
+}
+
+// FromLevel returns the slog level for a log.Level.
+func FromLevel(l log.Level) stdslog.Level {
+	switch l {
+	case log.DebugLevel:
+		return stdslog.LevelDebug
+	case log.InfoLevel:
+		return stdslog.LevelInfo
+	case log.WarnLevel:
+		return stdslog.LevelWarn
+	case log.ErrorLevel:
+		return stdslog.LevelError
+	default:
+		return LevelFatal
+	}
+}
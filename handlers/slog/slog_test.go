@@ -0,0 +1,122 @@
+//go:build go1.21
+// +build go1.21
+
+package slog_test
+
+import (
+	"bytes"
+	stdslog "log/slog"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/slog"
+)
+
+func init() {
+	log.Now = func() time.Time {
+		return time.Unix(0, 0).UTC()
+	}
+}
+
+func Test(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := &log.Logger{
+		Handler: slog.New(stdslog.NewJSONHandler(&buf, &stdslog.HandlerOptions{Level: stdslog.LevelDebug})),
+		Level:   log.DebugLevel,
+	}
+
+	l.WithField("user", "tj").WithField("request", log.Fields{"id": "123"}).Debug("hello")
+	l.Error("boom")
+
+	expected := `{"time":"1970-01-01T00:00:00Z","level":"DEBUG","msg":"hello","request":{"id":"123"},"user":"tj"}
+{"time":"1970-01-01T00:00:00Z","level":"ERROR","msg":"boom"}
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestAdapter(t *testing.T) {
+	h := memory.New()
+
+	l := stdslog.New(slog.NewAdapter(h, stdslog.LevelInfo))
+	l.Debug("uploading")
+	l.With("user", "tobi").WithGroup("file").Info("upload", "name", "sloth.png", "size", 1024)
+	l.Warn("retry", stdslog.Group("upload", "attempt", 2))
+
+	assert.Len(t, h.Entries, 2)
+
+	{
+		e := h.Entries[0]
+		assert.Equal(t, "upload", e.Message)
+		assert.Equal(t, "github.com/apex/log/handlers/slog_test.TestAdapter", e.Caller.Function)
+		assert.Equal(t, log.InfoLevel, e.Level)
+		assert.Equal(t, log.Fields{
+			"user": "tobi",
+			"file": log.Fields{"name": "sloth.png", "size": int64(1024)},
+		}, e.Fields)
+	}
+
+	{
+		e := h.Entries[1]
+		assert.Equal(t, "retry", e.Message)
+		assert.Equal(t, log.WarnLevel, e.Level)
+		assert.Equal(t, log.Fields{"upload": log.Fields{"attempt": int64(2)}}, e.Fields)
+	}
+}
+
+func TestAdapter_slogtest(t *testing.T) {
+	h := memory.New()
+
+	results := func() (v []map[string]interface{}) {
+		for _, e := range h.Entries {
+			m := toMap(e.Fields)
+			if !e.Timestamp.IsZero() {
+				m[stdslog.TimeKey] = e.Timestamp
+			}
+			m[stdslog.LevelKey] = slog.FromLevel(e.Level)
+			m[stdslog.MessageKey] = e.Message
+			v = append(v, m)
+		}
+		return
+	}
+
+	err := slogtest.TestHandler(slog.NewAdapter(h, nil), results)
+	assert.NoError(t, err)
+}
+
+func TestLevels(t *testing.T) {
+	levels := []log.Level{
+		log.DebugLevel,
+		log.InfoLevel,
+		log.WarnLevel,
+		log.ErrorLevel,
+		log.FatalLevel,
+	}
+
+	for _, l := range levels {
+		assert.Equal(t, l, slog.ToLevel(slog.FromLevel(l)))
+	}
+
+	assert.Equal(t, log.InfoLevel, slog.ToLevel(stdslog.LevelInfo+2))
+}
+
+// toMap converts fields to plain maps.
+func toMap(f log.Fields) map[string]interface{} {
+	m := map[string]interface{}{}
+
+	for k, v := range f {
+		if v, ok := v.(log.Fields); ok {
+			m[k] = toMap(v)
+			continue
+		}
+		m[k] = v
+	}
+
+	return m
+}
@@ -0,0 +1,446 @@
+// Package syslog implements a syslog handler supporting RFC 5424 and
+// RFC 3164 over UDP, TCP, TLS and unix sockets, including the local
+// syslog daemon.
+package syslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/go-logfmt/logfmt"
+)
+
+// Format of messages.
+type Format int
+
+// Formats available.
+const (
+	RFC5424 Format = iota
+	RFC3164
+)
+
+// Framing of messages on stream connections.
+type Framing int
+
+// Framing methods available.
+const (
+	// OctetCounting prefixes messages with their length, see RFC 6587.
+	OctetCounting Framing = iota
+
+	// NonTransparent terminates messages with a newline, which must
+	// therefore not occur within messages.
+	NonTransparent
+)
+
+// Facility of messages.
+type Facility int
+
+// Facilities available.
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	NTP
+	Security
+	Console
+	SolarisCron
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Severity returns the syslog severity for a level.
+func Severity(l log.Level) int {
+	switch l {
+	case log.DebugLevel:
+		return 7
+	case log.InfoLevel:
+		return 6
+	case log.WarnLevel:
+		return 4
+	case log.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// SDID is the RFC 5424 structured data id used for fields.
+const SDID = "fields@32473"
+
+// localPaths are the sockets tried for the local syslog daemon.
+var localPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Config for syslog.
+type Config struct {
+	// Network is "udp", "tcp", "tls", "unix" or "unixgram". When empty the
+	// local syslog daemon is used, and Address is ignored.
+	Network string
+
+	// Address such as "logs.example.com:514", or a socket path.
+	Address string
+
+	// TLSConfig used for the "tls" network.
+	TLSConfig *tls.Config
+
+	// Format of messages, defaults to RFC5424.
+	Format Format
+
+	// Framing used for "tcp" and "tls", defaults to OctetCounting. Unix
+	// stream sockets always use NonTransparent framing.
+	Framing Framing
+
+	// Facility of messages, defaults to User. Kern is reserved for the
+	// kernel, so is never used.
+	Facility Facility
+
+	// Hostname defaults to os.Hostname().
+	Hostname string
+
+	// AppName defaults to the program name.
+	AppName string
+
+	// Timeout for connecting and writing, defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// defaults applies the config defaults.
+func (c *Config) defaults() {
+	if c.Facility == Kern {
+		c.Facility = User
+	}
+
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+}
+
+// Handler implementation.
+type Handler struct {
+	*Config
+
+	pid string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	network string
+}
+
+// New handler. The connection is established on the first entry, and
+// re-established after a failure.
+func New(config *Config) *Handler {
+	config.defaults()
+
+	return &Handler{
+		Config: config,
+		pid:    strconv.Itoa(os.Getpid()),
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	var msg []byte
+
+	switch h.Format {
+	case RFC3164:
+		msg = h.rfc3164(e)
+	default:
+		msg = h.rfc5424(e)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err := h.write(msg)
+	if err == nil {
+		return nil
+	}
+
+	// reconnect and retry once
+	h.close()
+	return h.write(msg)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.close()
+}
+
+// close the connection, if any.
+func (h *Handler) close() error {
+	if h.conn == nil {
+		return nil
+	}
+
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+// write a message, connecting when necessary.
+func (h *Handler) write(msg []byte) error {
+	if h.conn == nil {
+		if err := h.connect(); err != nil {
+			return err
+		}
+	}
+
+	h.conn.SetWriteDeadline(time.Now().Add(h.Timeout))
+	_, err := h.conn.Write(h.frame(msg))
+	return err
+}
+
+// connect to the syslog server.
+func (h *Handler) connect() error {
+	switch h.Network {
+	case "":
+		return h.connectLocal()
+	case "tls":
+		d := &net.Dialer{Timeout: h.Timeout}
+		conn, err := tls.DialWithDialer(d, "tcp", h.Address, h.TLSConfig)
+		if err != nil {
+			return err
+		}
+		h.conn = conn
+	default:
+		conn, err := net.DialTimeout(h.Network, h.Address, h.Timeout)
+		if err != nil {
+			return err
+		}
+		h.conn = conn
+	}
+
+	h.network = h.Network
+	return nil
+}
+
+// connectLocal connects to the local syslog daemon.
+func (h *Handler) connectLocal() error {
+	for _, path := range localPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, path, h.Timeout)
+			if err == nil {
+				h.conn = conn
+				h.network = network
+				return nil
+			}
+		}
+	}
+
+	return errors.New("syslog: local syslog daemon unavailable")
+}
+
+// frame returns `msg` framed for the connection.
+func (h *Handler) frame(msg []byte) []byte {
+	switch h.network {
+	case "unix":
+		return append(msg, '\n')
+	case "tcp", "tls":
+		if h.Framing == NonTransparent {
+			return append(msg, '\n')
+		}
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	default:
+		return msg
+	}
+}
+
+// priority returns the priority value for a level.
+func (h *Handler) priority(l log.Level) int {
+	return int(h.Facility)*8 + Severity(l)
+}
+
+// rfc5424 returns the message formatted as RFC 5424, with fields
+// as structured data.
+func (h *Handler) rfc5424(e *log.Entry) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ",
+		h.priority(e.Level),
+		e.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		header(h.Hostname, 255),
+		header(h.AppName, 48),
+		header(h.pid, 128))
+
+	writeStructuredData(&b, e.Fields)
+
+	if e.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.Message)
+	}
+
+	return b.Bytes()
+}
+
+// rfc3164 returns the message formatted as RFC 3164, with fields
+// appended in logfmt.
+func (h *Handler) rfc3164(e *log.Entry) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "<%d>%s %s %s[%s]: %s",
+		h.priority(e.Level),
+		e.Timestamp.Format(time.Stamp),
+		h.Hostname,
+		h.AppName,
+		h.pid,
+		e.Message)
+
+	if len(e.Fields) > 0 {
+		var f bytes.Buffer
+		enc := logfmt.NewEncoder(&f)
+
+		for _, name := range fieldNames(e.Fields) {
+			enc.EncodeKeyval(name, fieldValue(e.Fields, name))
+		}
+
+		b.WriteByte(' ')
+		b.Write(f.Bytes())
+	}
+
+	return b.Bytes()
+}
+
+// writeStructuredData writes `fields` as an SD-ELEMENT, or the nil value.
+func writeStructuredData(b *bytes.Buffer, fields log.Fields) {
+	if len(fields) == 0 {
+		b.WriteByte('-')
+		return
+	}
+
+	b.WriteString("[" + SDID)
+
+	for _, name := range fieldNames(fields) {
+		b.WriteByte(' ')
+		b.WriteString(paramName(name))
+		b.WriteString(`="`)
+		b.WriteString(paramValue(fmt.Sprint(fieldValue(fields, name))))
+		b.WriteByte('"')
+	}
+
+	b.WriteByte(']')
+}
+
+// fieldNames returns the sorted field names, with nested fields
+// flattened as "parent.child".
+func fieldNames(fields log.Fields) []string {
+	var names []string
+
+	for k, v := range fields {
+		if n, ok := nested(v); ok {
+			for _, name := range fieldNames(n) {
+				names = append(names, k+"."+name)
+			}
+			continue
+		}
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// fieldValue returns the value of the field `name` as returned by fieldNames.
+func fieldValue(fields log.Fields, name string) interface{} {
+	if v, ok := fields[name]; ok {
+		return v
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if n, ok := nested(fields[parts[0]]); ok && len(parts) == 2 {
+		return fieldValue(n, parts[1])
+	}
+
+	return nil
+}
+
+// nested returns `v` as fields if it is nested fields.
+func nested(v interface{}) (log.Fields, bool) {
+	switch v := v.(type) {
+	case log.Fields:
+		return v, true
+	case map[string]interface{}:
+		return log.Fields(v), true
+	default:
+		return nil, false
+	}
+}
+
+// header returns `s` as a header field of at most `max` printable
+// ASCII characters, or the nil value.
+func header(s string, max int) string {
+	b := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > 32 && c < 127 {
+			b = append(b, c)
+		}
+	}
+
+	if len(b) == 0 {
+		return "-"
+	}
+
+	return string(b)
+}
+
+// paramName returns `s` as a valid SD-NAME, replacing invalid characters.
+func paramName(s string) string {
+	b := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s) && len(b) < 32; i++ {
+		c := s[i]
+		if c <= 32 || c >= 127 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+
+	if len(b) == 0 {
+		return "_"
+	}
+
+	return string(b)
+}
+
+// paramValue returns `s` with '"', '\' and ']' escaped.
+func paramValue(s string) string {
+	return valueEscaper.Replace(s)
+}
+
+// valueEscaper escapes SD-PARAM values.
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
@@ -0,0 +1,231 @@
+package syslog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+)
+
+var entry = &log.Entry{
+	Level:     log.WarnLevel,
+	Message:   "upload failed",
+	Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC),
+	Fields: log.Fields{
+		"user":    "tobi",
+		"path":    `C:\a "b" [c]`,
+		"request": log.Fields{"id": 5},
+		"bad key": true,
+	},
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, 7, Severity(log.DebugLevel))
+	assert.Equal(t, 6, Severity(log.InfoLevel))
+	assert.Equal(t, 4, Severity(log.WarnLevel))
+	assert.Equal(t, 3, Severity(log.ErrorLevel))
+	assert.Equal(t, 2, Severity(log.FatalLevel))
+}
+
+func TestHandler_rfc5424(t *testing.T) {
+	h := New(&Config{Hostname: "host", AppName: "my app", Facility: Local0})
+	h.pid = "42"
+
+	assert.Equal(t, `<132>1 2020-01-02T03:04:05.000006Z host myapp 42 - [fields@32473 bad_key="true" path="C:\\a \"b\" [c\]" request.id="5" user="tobi"] upload failed`, string(h.rfc5424(entry)))
+	assert.Equal(t, `<134>1 0001-01-01T00:00:00.000000Z host myapp 42 - -`, string(h.rfc5424(&log.Entry{Level: log.InfoLevel})))
+}
+
+func TestHandler_rfc3164(t *testing.T) {
+	h := New(&Config{Hostname: "host", AppName: "app", Format: RFC3164})
+	h.pid = "42"
+
+	assert.Equal(t, `<12>Jan  2 03:04:05 host app[42]: upload failed badkey=true path="C:\\a \"b\" [c]" request.id=5 user=tobi`, string(h.rfc3164(entry)))
+}
+
+func TestHandler_udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	h := New(&Config{Network: "udp", Address: conn.LocalAddr().String(), Hostname: "host", AppName: "app"})
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.ErrorLevel, Message: "boom"}))
+
+	b := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(b)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b[:n]), "<11>1 "))
+	assert.True(t, strings.HasSuffix(string(b[:n]), " - boom"))
+}
+
+// readFrame reads an octet-counted frame.
+func readFrame(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, n)
+	_, err = r.Read(b)
+	return string(b), err
+}
+
+func TestHandler_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	h := New(&Config{Network: "tcp", Address: l.Addr().String()})
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "hello\nworld"}))
+
+	conn := <-conns
+	s, err := readFrame(bufio.NewReader(conn))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(s, " - hello\nworld"))
+
+	// reconnects once the server closes the connection
+	conn.Close()
+
+	for i := 0; i < 100; i++ {
+		h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "again"})
+
+		select {
+		case conn := <-conns:
+			defer conn.Close()
+			s, err := readFrame(bufio.NewReader(conn))
+			assert.NoError(t, err)
+			assert.True(t, strings.HasSuffix(s, " - again"))
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("did not reconnect")
+}
+
+func TestHandler_connectFailure(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	h := New(&Config{Network: "tcp", Address: addr, Framing: NonTransparent})
+	defer h.Close()
+
+	assert.Error(t, h.HandleLog(&log.Entry{Message: "lost"}))
+
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip("address reused")
+	}
+	defer l.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Message: "hello"}))
+
+	conn, err := l.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	s, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(s, " - hello\n"))
+}
+
+func TestHandler_tls(t *testing.T) {
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: s.TLS.Certificates})
+	assert.NoError(t, err)
+	defer l.Close()
+
+	h := New(&Config{
+		Network:   "tls",
+		Address:   l.Addr().String(),
+		TLSConfig: s.Client().Transport.(*http.Transport).TLSClientConfig,
+		Format:    RFC3164,
+	})
+	defer h.Close()
+
+	done := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(done)
+			return
+		}
+		defer conn.Close()
+		s, _ := readFrame(bufio.NewReader(conn))
+		done <- s
+	}()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "secure"}))
+	assert.True(t, strings.HasSuffix(<-done, ": secure"))
+}
+
+func TestHandler_local(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	defer func(v []string) { localPaths = v }(localPaths)
+	localPaths = []string{filepath.Join(dir, "missing"), path}
+
+	h := New(&Config{})
+	defer h.Close()
+
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.DebugLevel, Message: "local"}))
+
+	b := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(b)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b[:n]), "<15>1 "))
+	assert.True(t, strings.HasSuffix(string(b[:n]), " - local"))
+}
+
+func TestHandler_localUnavailable(t *testing.T) {
+	defer func(v []string) { localPaths = v }(localPaths)
+	localPaths = []string{filepath.Join(os.TempDir(), "syslog-missing", "log")}
+
+	h := New(&Config{})
+	assert.EqualError(t, h.HandleLog(&log.Entry{}), "syslog: local syslog daemon unavailable")
+}
@@ -74,6 +74,10 @@ func (h *Handler) HandleLog(e *log.Entry) error {
 		fmt.Fprintf(h.Writer, " \033[%dm%s\033[0m=%v", color, name, e.Fields.Get(name))
 	}
 
+	if e.Caller != nil {
+		fmt.Fprintf(h.Writer, " \033[%dmcaller\033[0m=%s", gray, e.Caller)
+	}
+
 	fmt.Fprintln(h.Writer)
 
 	return nil
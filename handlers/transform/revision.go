@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package transform
+
+import "runtime/debug"
+
+// revision returns the version control revision from the build information.
+func revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+
+	if revision != "" && modified == "true" {
+		revision += "-dirty"
+	}
+
+	return revision
+}
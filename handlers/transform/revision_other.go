@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package transform
+
+// revision returns an empty string, the build information has no version
+// control settings before Go 1.18.
+func revision() string {
+	return ""
+}
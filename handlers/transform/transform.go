@@ -0,0 +1,334 @@
+// Package transform implements a handler which transforms the fields of
+// entries before passing them to another handler.
+package transform
+
+import (
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/apex/log"
+)
+
+// Func transforms an entry. It is passed a copy of the entry with a deep
+// copy of its fields, so it may modify them freely.
+type Func func(*log.Entry)
+
+// Handler implementation.
+type Handler struct {
+	Handler log.Handler
+	Funcs   []Func
+}
+
+// New handler applying `fns` in order to entries passed to `h`. The
+// original entry is never modified, so it is safe to use alongside other
+// handlers, for example with the multi handler.
+func New(h log.Handler, fns ...Func) *Handler {
+	return &Handler{
+		Handler: h,
+		Funcs:   fns,
+	}
+}
+
+// HandleLog implements log.Handler.
+func (h *Handler) HandleLog(e *log.Entry) error {
+	c := *e
+	c.Fields = copyFields(e.Fields)
+
+	for _, fn := range h.Funcs {
+		fn(&c)
+	}
+
+	return h.Handler.HandleLog(&c)
+}
+
+// Flush implements log.Flusher.
+func (h *Handler) Flush() error {
+	return log.FlushHandler(h.Handler)
+}
+
+// Close implements log.Closer.
+func (h *Handler) Close() error {
+	return log.CloseHandler(h.Handler)
+}
+
+// Rename renames the field `from` to `to`, replacing any existing field `to`.
+func Rename(from, to string) Func {
+	return func(e *log.Entry) {
+		if v, ok := e.Fields[from]; ok {
+			delete(e.Fields, from)
+			e.Fields[to] = v
+		}
+	}
+}
+
+// Drop removes the fields `names`.
+func Drop(names ...string) Func {
+	return func(e *log.Entry) {
+		for _, name := range names {
+			delete(e.Fields, name)
+		}
+	}
+}
+
+// Default sets the field `name` to `value` unless it is present.
+func Default(name string, value interface{}) Func {
+	return func(e *log.Entry) {
+		if _, ok := e.Fields[name]; !ok {
+			e.Fields[name] = value
+		}
+	}
+}
+
+// Set sets the field `name` to `value`, replacing any existing value.
+func Set(name string, value interface{}) Func {
+	return func(e *log.Entry) {
+		e.Fields[name] = value
+	}
+}
+
+// Add sets the field `name` to the value computed by `fn` for each entry,
+// replacing any existing value.
+func Add(name string, fn func(*log.Entry) interface{}) Func {
+	return func(e *log.Entry) {
+		e.Fields[name] = fn(e)
+	}
+}
+
+// Hostname defaults the field `name` to the hostname.
+func Hostname(name string) Func {
+	host, _ := os.Hostname()
+	return Default(name, host)
+}
+
+// PID defaults the field `name` to the process id.
+func PID(name string) Func {
+	return Default(name, os.Getpid())
+}
+
+// Service defaults the field `name` to `service`.
+func Service(name, service string) Func {
+	return Default(name, service)
+}
+
+// Version defaults the field `name` to the main module version from the
+// build information, if any.
+func Version(name string) Func {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return noop
+	}
+
+	return Default(name, info.Main.Version)
+}
+
+// Revision defaults the field `name` to the version control revision, such
+// as the git sha, from the build information, if any. The revision has a
+// "-dirty" suffix when built with local modifications. It requires Go 1.18.
+func Revision(name string) Func {
+	v := revision()
+	if v == "" {
+		return noop
+	}
+
+	return Default(name, v)
+}
+
+// Keys renames all fields, including nested fields, using `fn`, for
+// example Keys(SnakeCase).
+func Keys(fn func(string) string) Func {
+	return func(e *log.Entry) {
+		e.Fields = renameKeys(e.Fields, fn)
+	}
+}
+
+// Prefix adds `prefix` to the names of all top-level fields, except `except`
+// and fields which already have the prefix.
+func Prefix(prefix string, except ...string) Func {
+	skip := make(map[string]bool, len(except))
+	for _, name := range except {
+		skip[name] = true
+	}
+
+	return func(e *log.Entry) {
+		f := make(log.Fields, len(e.Fields))
+
+		for k, v := range e.Fields {
+			if skip[k] || strings.HasPrefix(k, prefix) {
+				f[k] = v
+				continue
+			}
+			f[prefix+k] = v
+		}
+
+		e.Fields = f
+	}
+}
+
+// Flatten replaces nested fields with top-level fields, joining names with
+// `sep`, for example {"user": {"id": 1}} becomes {"user.id": 1} with ".".
+func Flatten(sep string) Func {
+	return func(e *log.Entry) {
+		f := make(log.Fields, len(e.Fields))
+		flatten(f, "", sep, e.Fields)
+		e.Fields = f
+	}
+}
+
+// Nest replaces fields with names containing `sep` with nested fields,
+// the inverse of Flatten. Fields conflicting with an existing non-nested
+// field are left as-is.
+func Nest(sep string) Func {
+	return func(e *log.Entry) {
+		names := e.Fields.Names()
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !strings.Contains(name, sep) {
+				continue
+			}
+
+			parts := strings.Split(name, sep)
+			if nest(e.Fields, parts[:len(parts)-1], parts[len(parts)-1], e.Fields[name]) {
+				delete(e.Fields, name)
+			}
+		}
+	}
+}
+
+// SnakeCase converts names such as "userID" or "user-name" to "user_id"
+// and "user_name".
+func SnakeCase(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+
+	for i, c := range r {
+		switch {
+		case c == '-' || c == ' ':
+			b.WriteRune('_')
+			continue
+		case unicode.IsUpper(c) && i > 0:
+			prev := r[i-1]
+			next := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteRune('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(c))
+	}
+
+	return b.String()
+}
+
+// CamelCase converts names such as "user_id" or "user-name" to "userId"
+// and "userName".
+func CamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+
+	for i, c := range s {
+		switch {
+		case c == '_' || c == '-' || c == ' ':
+			upper = b.Len() > 0
+		case upper:
+			b.WriteRune(unicode.ToUpper(c))
+			upper = false
+		case i == 0:
+			b.WriteRune(unicode.ToLower(c))
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// noop transformation.
+func noop(*log.Entry) {}
+
+// nested returns `v` as fields if it is nested fields.
+func nested(v interface{}) (log.Fields, bool) {
+	switch v := v.(type) {
+	case log.Fields:
+		return v, true
+	case map[string]interface{}:
+		return log.Fields(v), true
+	default:
+		return nil, false
+	}
+}
+
+// copyFields returns a deep copy of the nested fields in `fields`.
+func copyFields(fields log.Fields) log.Fields {
+	f := make(log.Fields, len(fields))
+
+	for k, v := range fields {
+		if n, ok := nested(v); ok {
+			f[k] = copyFields(n)
+			continue
+		}
+		f[k] = v
+	}
+
+	return f
+}
+
+// renameKeys returns `fields` with all names converted by `fn`.
+func renameKeys(fields log.Fields, fn func(string) string) log.Fields {
+	f := make(log.Fields, len(fields))
+
+	for k, v := range fields {
+		if n, ok := nested(v); ok {
+			v = renameKeys(n, fn)
+		}
+		f[fn(k)] = v
+	}
+
+	return f
+}
+
+// flatten adds the fields in `fields` to `f`, prefixing names with `prefix`.
+func flatten(f log.Fields, prefix, sep string, fields log.Fields) {
+	for k, v := range fields {
+		if prefix != "" {
+			k = prefix + sep + k
+		}
+
+		if n, ok := nested(v); ok && len(n) > 0 {
+			flatten(f, k, sep, n)
+			continue
+		}
+
+		f[k] = v
+	}
+}
+
+// nest sets `value` as `name` in `fields`, nested under `path`. It returns
+// false when a non-nested field is in the way.
+func nest(fields log.Fields, path []string, name string, value interface{}) bool {
+	for _, p := range path {
+		v, ok := fields[p]
+		if !ok {
+			n := log.Fields{}
+			fields[p] = n
+			fields = n
+			continue
+		}
+
+		n, ok := nested(v)
+		if !ok {
+			return false
+		}
+		fields = n
+	}
+
+	if _, ok := fields[name]; ok {
+		return false
+	}
+
+	fields[name] = value
+	return true
+}
@@ -0,0 +1,151 @@
+package transform_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/apex/log/handlers/multi"
+	"github.com/apex/log/handlers/transform"
+)
+
+func Test(t *testing.T) {
+	a := memory.New()
+	b := memory.New()
+
+	ctx := log.Logger{
+		Handler: multi.New(
+			transform.New(a,
+				transform.Rename("user", "username"),
+				transform.Drop("password"),
+				transform.Default("env", "production"),
+				transform.Set("service", "api"),
+				transform.Add("length", func(e *log.Entry) interface{} { return len(e.Message) }),
+				transform.Keys(transform.SnakeCase),
+			),
+			b,
+		),
+		Level: log.InfoLevel,
+	}
+
+	ctx.WithFields(log.Fields{
+		"user":     "tobi",
+		"password": "secret",
+		"env":      "staging",
+		"service":  "web",
+		"request":  log.Fields{"requestID": "abc"},
+	}).Info("upload")
+
+	assert.Equal(t, log.Fields{
+		"username": "tobi",
+		"env":      "staging",
+		"service":  "api",
+		"length":   6,
+		"request":  log.Fields{"request_id": "abc"},
+	}, a.Entries[0].Fields)
+
+	assert.Equal(t, log.Fields{
+		"user":     "tobi",
+		"password": "secret",
+		"env":      "staging",
+		"service":  "web",
+		"request":  log.Fields{"requestID": "abc"},
+	}, b.Entries[0].Fields)
+}
+
+func apply(fields log.Fields, fns ...transform.Func) log.Fields {
+	h := memory.New()
+	transform.New(h, fns...).HandleLog(&log.Entry{Fields: fields})
+	return h.Entries[0].Fields
+}
+
+func TestMetadata(t *testing.T) {
+	host, _ := os.Hostname()
+
+	f := apply(log.Fields{"pid": "custom"},
+		transform.Hostname("host"),
+		transform.PID("pid"),
+		transform.Service("service", "api"),
+		transform.Version("version"),
+		transform.Revision("revision"),
+	)
+
+	assert.Equal(t, host, f["host"])
+	assert.Equal(t, "custom", f["pid"])
+	assert.Equal(t, "api", f["service"])
+}
+
+func TestPrefix(t *testing.T) {
+	f := apply(log.Fields{"user": "tobi", "_id": 1, "host": "a"}, transform.Prefix("_", "host"))
+	assert.Equal(t, log.Fields{"_user": "tobi", "_id": 1, "host": "a"}, f)
+}
+
+func TestFlatten(t *testing.T) {
+	f := apply(log.Fields{
+		"user": log.Fields{
+			"id":   1,
+			"name": map[string]interface{}{"first": "Tobi"},
+		},
+		"empty": log.Fields{},
+		"size":  5,
+	}, transform.Flatten("."))
+
+	assert.Equal(t, log.Fields{
+		"user.id":         1,
+		"user.name.first": "Tobi",
+		"empty":           log.Fields{},
+		"size":            5,
+	}, f)
+}
+
+func TestNest(t *testing.T) {
+	f := apply(log.Fields{
+		"user.id":         1,
+		"user.name.first": "Tobi",
+		"size":            5,
+		"size.unit":       "kb",
+	}, transform.Nest("."))
+
+	assert.Equal(t, log.Fields{
+		"user": log.Fields{
+			"id":   1,
+			"name": log.Fields{"first": "Tobi"},
+		},
+		"size":      5,
+		"size.unit": "kb",
+	}, f)
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"user":       "user",
+		"userID":     "user_id",
+		"UserName":   "user_name",
+		"HTTPStatus": "http_status",
+		"user-name":  "user_name",
+		"ipv4Addr":   "ipv4_addr",
+		"user_id":    "user_id",
+	}
+
+	for in, out := range cases {
+		assert.Equal(t, out, transform.SnakeCase(in), in)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	cases := map[string]string{
+		"user":       "user",
+		"user_id":    "userId",
+		"user-name":  "userName",
+		"UserName":   "userName",
+		"_private":   "private",
+		"http__code": "httpCode",
+	}
+
+	for in, out := range cases {
+		assert.Equal(t, out, transform.CamelCase(in), in)
+	}
+}
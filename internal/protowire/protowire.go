@@ -0,0 +1,171 @@
+// Package protowire implements the minimal subset of the protocol buffers
+// wire format needed to encode messages by hand, avoiding a dependency on
+// generated code.
+package protowire
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Type is a wire type.
+type Type int
+
+// Wire types.
+const (
+	Varint  Type = 0
+	Fixed64 Type = 1
+	Bytes   Type = 2
+	Fixed32 Type = 5
+)
+
+// ErrTruncated is returned when consuming truncated input.
+var ErrTruncated = errors.New("protowire: truncated input")
+
+// AppendTag appends the tag of field `num` with wire type `t`.
+func AppendTag(b []byte, num int, t Type) []byte {
+	return AppendVarint(b, uint64(num)<<3|uint64(t))
+}
+
+// AppendVarint appends `v` as a varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// AppendFixed32 appends `v` in little-endian order.
+func AppendFixed32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// AppendFixed64 appends `v` in little-endian order.
+func AppendFixed64(b []byte, v uint64) []byte {
+	return append(b,
+		byte(v), byte(v>>8), byte(v>>16), byte(v>>24),
+		byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56))
+}
+
+// AppendBytes appends `v` prefixed with its length.
+func AppendBytes(b []byte, v []byte) []byte {
+	b = AppendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendString appends `s` prefixed with its length.
+func AppendString(b []byte, s string) []byte {
+	b = AppendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// AppendVarintField appends field `num` as a varint.
+func AppendVarintField(b []byte, num int, v uint64) []byte {
+	b = AppendTag(b, num, Varint)
+	return AppendVarint(b, v)
+}
+
+// AppendFixed32Field appends field `num` as a fixed32.
+func AppendFixed32Field(b []byte, num int, v uint32) []byte {
+	b = AppendTag(b, num, Fixed32)
+	return AppendFixed32(b, v)
+}
+
+// AppendFixed64Field appends field `num` as a fixed64.
+func AppendFixed64Field(b []byte, num int, v uint64) []byte {
+	b = AppendTag(b, num, Fixed64)
+	return AppendFixed64(b, v)
+}
+
+// AppendDoubleField appends field `num` as a double.
+func AppendDoubleField(b []byte, num int, v float64) []byte {
+	return AppendFixed64Field(b, num, math.Float64bits(v))
+}
+
+// AppendBytesField appends field `num` as length-delimited bytes, which
+// may be an embedded message.
+func AppendBytesField(b []byte, num int, v []byte) []byte {
+	b = AppendTag(b, num, Bytes)
+	return AppendBytes(b, v)
+}
+
+// AppendStringField appends field `num` as a string.
+func AppendStringField(b []byte, num int, s string) []byte {
+	b = AppendTag(b, num, Bytes)
+	return AppendString(b, s)
+}
+
+// Field is a decoded field. Varint, Fixed32 and Fixed64 values are
+// stored in Value, and Bytes values in Bytes.
+type Field struct {
+	Num   int
+	Type  Type
+	Value uint64
+	Bytes []byte
+}
+
+// ConsumeField decodes the field at the start of `b`, returning it and the
+// number of bytes consumed.
+func ConsumeField(b []byte) (Field, int, error) {
+	tag, n := binary.Uvarint(b)
+	if n <= 0 {
+		return Field{}, 0, ErrTruncated
+	}
+
+	f := Field{
+		Num:  int(tag >> 3),
+		Type: Type(tag & 7),
+	}
+
+	switch f.Type {
+	case Varint:
+		v, m := binary.Uvarint(b[n:])
+		if m <= 0 {
+			return Field{}, 0, ErrTruncated
+		}
+		f.Value = v
+		n += m
+	case Fixed32:
+		if len(b[n:]) < 4 {
+			return Field{}, 0, ErrTruncated
+		}
+		f.Value = uint64(binary.LittleEndian.Uint32(b[n:]))
+		n += 4
+	case Fixed64:
+		if len(b[n:]) < 8 {
+			return Field{}, 0, ErrTruncated
+		}
+		f.Value = binary.LittleEndian.Uint64(b[n:])
+		n += 8
+	case Bytes:
+		l, m := binary.Uvarint(b[n:])
+		if m <= 0 || uint64(len(b[n+m:])) < l {
+			return Field{}, 0, ErrTruncated
+		}
+		n += m
+		f.Bytes = b[n : n+int(l)]
+		n += int(l)
+	default:
+		return Field{}, 0, errors.New("protowire: unsupported wire type")
+	}
+
+	return f, n, nil
+}
+
+// ConsumeFields decodes all fields in `b`.
+func ConsumeFields(b []byte) ([]Field, error) {
+	var fields []Field
+
+	for len(b) > 0 {
+		f, n, err := ConsumeField(b)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		b = b[n:]
+	}
+
+	return fields, nil
+}
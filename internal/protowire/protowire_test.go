@@ -0,0 +1,46 @@
+package protowire_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log/internal/protowire"
+)
+
+func Test(t *testing.T) {
+	var b []byte
+	b = protowire.AppendVarintField(b, 1, 300)
+	b = protowire.AppendStringField(b, 2, "hello")
+	b = protowire.AppendFixed64Field(b, 3, 1<<40)
+	b = protowire.AppendFixed32Field(b, 4, 7)
+	b = protowire.AppendDoubleField(b, 5, 1.5)
+	b = protowire.AppendBytesField(b, 16, nil)
+
+	assert.Equal(t, []byte{
+		0x08, 0xac, 0x02,
+		0x12, 0x05, 'h', 'e', 'l', 'l', 'o',
+		0x19, 0, 0, 0, 0, 0, 1, 0, 0,
+		0x25, 7, 0, 0, 0,
+		0x29, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f,
+		0x82, 0x01, 0x00,
+	}, b)
+
+	fields, err := protowire.ConsumeFields(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []protowire.Field{
+		{Num: 1, Type: protowire.Varint, Value: 300},
+		{Num: 2, Type: protowire.Bytes, Bytes: []byte("hello")},
+		{Num: 3, Type: protowire.Fixed64, Value: 1 << 40},
+		{Num: 4, Type: protowire.Fixed32, Value: 7},
+		{Num: 5, Type: protowire.Fixed64, Value: math.Float64bits(1.5)},
+		{Num: 16, Type: protowire.Bytes, Bytes: []byte{}},
+	}, fields)
+
+	_, err = protowire.ConsumeFields(b[:len(b)-1])
+	assert.Equal(t, protowire.ErrTruncated, err)
+
+	_, err = protowire.ConsumeFields([]byte{0x12, 0x05, 'h'})
+	assert.Equal(t, protowire.ErrTruncated, err)
+}
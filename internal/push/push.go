@@ -0,0 +1,48 @@
+// Package push implements sending batches of entries to HTTP push APIs,
+// for use as the batch function of handlers such as loki and otlp.
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/apex/log/handlers/batch"
+)
+
+// Post sends `body` to `url`, calling `fn` to set request headers. Failed
+// responses return an error including the start of the response body,
+// which is marked permanent unless the status is 429 or 5xx.
+func Post(ctx context.Context, client *http.Client, url string, body []byte, fn func(*http.Request)) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return batch.Permanent(err)
+	}
+
+	req = req.WithContext(ctx)
+	fn(req)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 300 {
+		io.Copy(ioutil.Discard, res.Body)
+		return nil
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	err = fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(b)))
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return err
+	}
+
+	return batch.Permanent(err)
+}
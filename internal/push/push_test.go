@@ -0,0 +1,37 @@
+package push_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/batch"
+	"github.com/apex/log/internal/push"
+	"github.com/apex/log/internal/push/pushtest"
+)
+
+func TestPost(t *testing.T) {
+	s := pushtest.NewServer(http.StatusNoContent, http.StatusServiceUnavailable, http.StatusBadRequest)
+	defer s.Close()
+
+	h := batch.New(func(ctx context.Context, entries []*log.Entry) error {
+		return push.Post(ctx, http.DefaultClient, s.URL, []byte("hello"), func(r *http.Request) {
+			r.Header.Set("Content-Type", "text/plain")
+		})
+	}, batch.WithRetries(3, time.Millisecond), batch.WithErrorHandler(func(error) {}))
+
+	// 503 is retried, 400 is not
+	assert.NoError(t, h.HandleLog(&log.Entry{}))
+	assert.EqualError(t, h.Flush(), "batch: 400 Bad Request: error details")
+	assert.Len(t, s.Requests, 2)
+
+	assert.NoError(t, h.HandleLog(&log.Entry{}))
+	assert.NoError(t, h.Close())
+	assert.Len(t, s.Requests, 3)
+	assert.Equal(t, "text/plain", s.Requests[2].Header.Get("Content-Type"))
+	assert.Equal(t, "hello", string(s.Bodies[2]))
+}
@@ -0,0 +1,46 @@
+// Package pushtest implements a server recording push requests, for
+// testing handlers using package push.
+package pushtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Server records push requests, responding with the given status codes
+// in turn and the success code thereafter.
+type Server struct {
+	*httptest.Server
+
+	Requests []*http.Request
+	Bodies   [][]byte
+
+	mu    sync.Mutex
+	codes []int
+}
+
+// NewServer returns a started server responding with `ok` once `codes`
+// have been used. The caller should call Close when finished.
+func NewServer(ok int, codes ...int) *Server {
+	s := &Server{codes: codes}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.Requests = append(s.Requests, r)
+		s.Bodies = append(s.Bodies, b)
+		code := ok
+		if len(s.codes) > 0 {
+			code, s.codes = s.codes[0], s.codes[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(code)
+		w.Write([]byte("error details"))
+	}))
+
+	return s
+}
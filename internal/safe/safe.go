@@ -0,0 +1,32 @@
+// Package safe implements calls into user code which recover from panics,
+// such as handlers and the String methods of field values.
+package safe
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+)
+
+// HandleLog passes the entry to `h`, returning panics as errors.
+func HandleLog(h log.Handler, e *log.Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return h.HandleLog(e)
+}
+
+// String returns the result of `fn`, the Error or String method of `v`,
+// falling back to fmt.Sprint when it panics, such as for a nil pointer.
+func String(v interface{}, fn func() string) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = fmt.Sprint(v)
+		}
+	}()
+
+	return fn()
+}
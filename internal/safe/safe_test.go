@@ -0,0 +1,32 @@
+package safe_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/internal/safe"
+)
+
+func TestHandleLog(t *testing.T) {
+	err := safe.HandleLog(log.HandlerFunc(func(*log.Entry) error {
+		panic("boom")
+	}), &log.Entry{})
+	assert.EqualError(t, err, "panic: boom")
+
+	err = safe.HandleLog(log.HandlerFunc(func(*log.Entry) error {
+		return errors.New("unavailable")
+	}), &log.Entry{})
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestString(t *testing.T) {
+	var u *url.URL
+	assert.Equal(t, "<nil>", safe.String(u, u.String))
+
+	u = &url.URL{Scheme: "https", Host: "apex.sh"}
+	assert.Equal(t, "https://apex.sh", safe.String(u, u.String))
+}
@@ -1,8 +1,9 @@
 package log
 
 import (
-	stdlog "log"
+	"context"
 	"sort"
+	"sync/atomic"
 	"time"
 )
 
@@ -56,10 +57,164 @@ type Handler interface {
 	HandleLog(*Entry) error
 }
 
+// Flusher is implemented by handlers which buffer entries, Flush
+// blocks until buffered entries have been written.
+type Flusher interface {
+	Flush() error
+}
+
+// Closer is implemented by handlers which buffer entries or hold
+// resources, Close blocks until buffered entries have been written
+// and releases the resources.
+type Closer interface {
+	Close() error
+}
+
 // Logger represents a logger with configurable Level and Handler.
+//
+// The Level and Handler fields provide the initial configuration, once
+// the logger is in use SetLevel and SetHandler should be used to change
+// them, as they are safe for concurrent use.
+//
+// When ReportCaller is true the file, line and function of the
+// log call are recorded in each Entry's Caller field, once the logger
+// is in use SetReportCaller should be used to change it.
+//
+// ErrorHandler is called with a *HandlerError when the handler fails,
+// by default errors are written to the stdlib log.
 type Logger struct {
-	Handler Handler
-	Level   Level
+	Handler      Handler
+	Level        Level
+	ReportCaller bool
+	ErrorHandler func(error)
+
+	name         string
+	parent       *Logger
+	level        atomic.Value // Level
+	handler      atomic.Value // handlerValue
+	errorHandler atomic.Value // errorHandlerValue
+	reportCaller atomic.Value // bool
+}
+
+// errorHandlerValue wraps error handlers so they may be stored in atomic.Value.
+type errorHandlerValue struct {
+	fn func(error)
+}
+
+// handlerValue wraps handlers so that atomic.Value always stores
+// the same concrete type.
+type handlerValue struct {
+	Handler
+}
+
+// SetLevel sets the level. This is thread-safe.
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(level)
+}
+
+// GetLevel returns the current level. Named loggers without a level
+// of their own use the named levels, falling back to their parent's level.
+func (l *Logger) GetLevel() Level {
+	if v, ok := l.level.Load().(Level); ok {
+		return v
+	}
+
+	if l.parent != nil {
+		if v, ok := namedLevels.lookup(l.name); ok {
+			return v
+		}
+
+		return l.parent.GetLevel()
+	}
+
+	return l.Level
+}
+
+// SetHandler sets the handler. This is thread-safe.
+func (l *Logger) SetHandler(h Handler) {
+	l.handler.Store(handlerValue{h})
+}
+
+// GetHandler returns the current handler. Named loggers without a
+// handler of their own use their parent's handler.
+func (l *Logger) GetHandler() Handler {
+	if v, ok := l.handler.Load().(handlerValue); ok {
+		return v.Handler
+	}
+
+	if l.Handler == nil && l.parent != nil {
+		return l.parent.GetHandler()
+	}
+
+	return l.Handler
+}
+
+// SetErrorHandler sets the function called when the handler fails. This is thread-safe.
+func (l *Logger) SetErrorHandler(fn func(error)) {
+	l.errorHandler.Store(errorHandlerValue{fn})
+}
+
+// GetErrorHandler returns the current error handler. Named loggers without
+// an error handler of their own use their parent's.
+func (l *Logger) GetErrorHandler() func(error) {
+	if v, ok := l.errorHandler.Load().(errorHandlerValue); ok && v.fn != nil {
+		return v.fn
+	}
+
+	if l.ErrorHandler != nil {
+		return l.ErrorHandler
+	}
+
+	if l.parent != nil {
+		return l.parent.GetErrorHandler()
+	}
+
+	return HandleError
+}
+
+// SetReportCaller sets whether the caller is recorded. This is thread-safe.
+func (l *Logger) SetReportCaller(v bool) {
+	l.reportCaller.Store(v)
+}
+
+// GetReportCaller returns true if the caller is recorded. Named loggers
+// without ReportCaller set of their own use their parent's.
+func (l *Logger) GetReportCaller() bool {
+	if v, ok := l.reportCaller.Load().(bool); ok {
+		return v
+	}
+
+	if !l.ReportCaller && l.parent != nil {
+		return l.parent.GetReportCaller()
+	}
+
+	return l.ReportCaller
+}
+
+// Named returns a child logger with `name` appended to the logger's
+// name, separated by a dot, for example "db.pool". Entries have the
+// name set in the "logger" field, and the level may be set per name
+// with SetNamedLevel.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	return &Logger{
+		name:   name,
+		parent: l,
+	}
+}
+
+// Name returns the logger's name.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Enabled returns true if entries of the given level are logged, this may be
+// used to skip expensive work when the level is not met.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.GetLevel()
 }
 
 // WithFields returns a new entry with `fields` set.
@@ -86,6 +241,12 @@ func (l *Logger) WithError(err error) *Entry {
 	return NewEntry(l).WithError(err)
 }
 
+// WithContext returns a new entry with the fields stored in `ctx`
+// and those returned by registered extractors.
+func (l *Logger) WithContext(ctx context.Context) *Entry {
+	return NewEntry(l).WithContext(ctx)
+}
+
 // Debug level message.
 func (l *Logger) Debug(msg string) {
 	NewEntry(l).Debug(msg)
@@ -146,11 +307,23 @@ func (l *Logger) Trace(msg string) *Entry {
 // to bypass the overhead in Entry methods when the level is not
 // met.
 func (l *Logger) log(level Level, e *Entry, msg string) {
-	if level < l.Level {
+	if level < l.GetLevel() {
 		return
 	}
 
-	if err := l.Handler.HandleLog(e.finalize(level, msg)); err != nil {
-		stdlog.Printf("error logging: %s", err)
+	entry := e.finalize(level, msg)
+
+	if l.name != "" {
+		entry.Fields["logger"] = l.name
+	}
+
+	if l.GetReportCaller() {
+		entry.Caller = caller()
+	}
+
+	h := l.GetHandler()
+
+	if err := h.HandleLog(entry); err != nil {
+		l.GetErrorHandler()(NewHandlerError(h, err, 1))
 	}
 }
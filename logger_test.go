@@ -1,7 +1,11 @@
 package log_test
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/apex/log"
@@ -194,6 +198,179 @@ func TestLogger_HandlerFunc(t *testing.T) {
 	assert.Equal(t, e.Level, log.InfoLevel)
 }
 
+func TestLogger_ReportCaller(t *testing.T) {
+	h := memory.New()
+
+	l := &log.Logger{
+		Handler:      h,
+		Level:        log.InfoLevel,
+		ReportCaller: true,
+	}
+
+	_, file, line, _ := runtime.Caller(0)
+	l.Info("upload")
+	l.WithField("file", "sloth.png").Infof("upload %s", "complete")
+
+	func() (err error) {
+		defer l.Trace("upload").Stop(&err)
+		return nil
+	}()
+
+	assert.Equal(t, 4, len(h.Entries))
+
+	{
+		e := h.Entries[0]
+		assert.Equal(t, file, e.Caller.File)
+		assert.Equal(t, line+1, e.Caller.Line)
+		assert.Equal(t, "github.com/apex/log_test.TestLogger_ReportCaller", e.Caller.Function)
+		assert.Equal(t, fmt.Sprintf("%s/logger_test.go:%d", filepath.Base(filepath.Dir(file)), line+1), e.Caller.String())
+	}
+
+	{
+		e := h.Entries[1]
+		assert.Equal(t, line+2, e.Caller.Line)
+	}
+
+	{
+		e := h.Entries[3]
+		assert.Equal(t, "github.com/apex/log_test.TestLogger_ReportCaller.func1", e.Caller.Function)
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	h := memory.New()
+
+	l := &log.Logger{
+		Handler: h,
+		Level:   log.InfoLevel,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.SetLevel(log.ErrorLevel)
+			l.Info("upload")
+		}()
+	}
+	wg.Wait()
+
+	l.Error("boom")
+
+	assert.Equal(t, log.ErrorLevel, l.GetLevel())
+	assert.True(t, len(h.Entries) >= 1)
+	assert.Equal(t, "boom", h.Entries[len(h.Entries)-1].Message)
+}
+
+func TestLogger_SetReportCaller(t *testing.T) {
+	h := memory.New()
+
+	l := &log.Logger{
+		Handler: h,
+		Level:   log.InfoLevel,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.SetReportCaller(true)
+			l.Info("upload")
+		}()
+	}
+	wg.Wait()
+
+	l.Info("upload")
+
+	assert.True(t, l.GetReportCaller())
+	assert.NotNil(t, h.Entries[len(h.Entries)-1].Caller)
+}
+
+func TestLogger_SetHandler(t *testing.T) {
+	a := memory.New()
+	b := memory.New()
+
+	l := &log.Logger{
+		Handler: a,
+		Level:   log.InfoLevel,
+	}
+
+	l.Info("hello")
+	l.SetHandler(b)
+	l.Info("world")
+
+	assert.Equal(t, b, l.GetHandler())
+	assert.Len(t, a.Entries, 1)
+	assert.Len(t, b.Entries, 1)
+	assert.Equal(t, "world", b.Entries[0].Message)
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	l := &log.Logger{
+		Handler: discard.New(),
+		Level:   log.WarnLevel,
+	}
+
+	assert.False(t, l.Enabled(log.InfoLevel))
+	assert.True(t, l.Enabled(log.WarnLevel))
+	assert.True(t, l.Enabled(log.ErrorLevel))
+
+	l.SetLevel(log.DebugLevel)
+	assert.True(t, l.Enabled(log.DebugLevel))
+}
+
+func TestLogger_ErrorHandler(t *testing.T) {
+	var errs []error
+
+	l := &log.Logger{
+		Handler: log.HandlerFunc(func(e *log.Entry) error {
+			return fmt.Errorf("boom")
+		}),
+		Level: log.InfoLevel,
+		ErrorHandler: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	l.Info("upload")
+	l.Named("db").Info("query")
+
+	assert.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "log.HandlerFunc: boom")
+
+	var e *log.HandlerError
+	assert.True(t, errors.As(errs[0], &e))
+	assert.Equal(t, "log.HandlerFunc", e.Handler)
+	assert.Equal(t, 1, e.Entries)
+
+	l.SetErrorHandler(func(err error) {
+		errs = append(errs, err)
+		errs = append(errs, err)
+	})
+
+	l.Info("upload")
+	assert.Len(t, errs, 4)
+}
+
+func TestLogger_ErrorHandler_handlerError(t *testing.T) {
+	var errs []error
+
+	l := &log.Logger{
+		Handler: log.HandlerFunc(func(e *log.Entry) error {
+			return &log.HandlerError{Handler: "es", Entries: 100, Err: fmt.Errorf("timeout")}
+		}),
+		Level: log.InfoLevel,
+		ErrorHandler: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	l.Info("upload")
+	assert.EqualError(t, errs[0], "es: 100 entries lost: timeout")
+}
+
 func BenchmarkLogger_small(b *testing.B) {
 	l := &log.Logger{
 		Handler: discard.New(),
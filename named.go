@@ -0,0 +1,132 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// namedLevels is the registry of levels for named loggers.
+var namedLevels levelRules
+
+// levelRules maps logger names to levels. Reads are lock-free,
+// writes replace the map.
+type levelRules struct {
+	mu    sync.Mutex
+	rules atomic.Value // map[string]Level
+}
+
+// load returns the current rules, which must not be modified.
+func (r *levelRules) load() map[string]Level {
+	m, _ := r.rules.Load().(map[string]Level)
+	return m
+}
+
+// update applies fn to a copy of the rules and stores the result.
+func (r *levelRules) update(fn func(map[string]Level)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := map[string]Level{}
+	for k, v := range r.load() {
+		m[k] = v
+	}
+
+	fn(m)
+	r.rules.Store(m)
+}
+
+// lookup returns the level for `name`, using the longest
+// matching dot-separated prefix.
+func (r *levelRules) lookup(name string) (Level, bool) {
+	m := r.load()
+	if len(m) == 0 {
+		return InvalidLevel, false
+	}
+
+	for {
+		if l, ok := m[name]; ok {
+			return l, true
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return InvalidLevel, false
+		}
+
+		name = name[:i]
+	}
+}
+
+// SetNamedLevel sets the level for loggers named `name`, and their
+// descendants unless they have a more specific level. This is thread-safe.
+func SetNamedLevel(name string, l Level) {
+	namedLevels.update(func(m map[string]Level) {
+		m[name] = l
+	})
+}
+
+// UnsetNamedLevel removes the level for loggers named `name`. This is thread-safe.
+func UnsetNamedLevel(name string) {
+	namedLevels.update(func(m map[string]Level) {
+		delete(m, name)
+	})
+}
+
+// SetNamedLevelsFromString replaces all named levels with those
+// in a comma-separated string such as "db=debug,http=warn". This is thread-safe.
+func SetNamedLevelsFromString(s string) error {
+	levels, err := ParseNamedLevels(s)
+	if err != nil {
+		return err
+	}
+
+	namedLevels.update(func(m map[string]Level) {
+		for k := range m {
+			delete(m, k)
+		}
+
+		for k, v := range levels {
+			m[k] = v
+		}
+	})
+
+	return nil
+}
+
+// NamedLevels returns a copy of the named levels.
+func NamedLevels() map[string]Level {
+	m := map[string]Level{}
+	for k, v := range namedLevels.load() {
+		m[k] = v
+	}
+	return m
+}
+
+// ParseNamedLevels parses a comma-separated string of named levels
+// such as "db=debug,http=warn".
+func ParseNamedLevels(s string) (map[string]Level, error) {
+	m := map[string]Level{}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid named level %q", part)
+		}
+
+		l, err := ParseLevel(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid named level %q: %w", part, err)
+		}
+
+		m[strings.TrimSpace(kv[0])] = l
+	}
+
+	return m, nil
+}
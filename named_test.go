@@ -0,0 +1,81 @@
+package log_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+)
+
+func TestLogger_Named(t *testing.T) {
+	h := memory.New()
+
+	l := &log.Logger{
+		Handler: h,
+		Level:   log.InfoLevel,
+	}
+
+	db := l.Named("db")
+	pool := db.Named("pool")
+	http := l.Named("http")
+
+	assert.Equal(t, "db.pool", pool.Name())
+
+	defer log.SetNamedLevelsFromString("")
+	assert.NoError(t, log.SetNamedLevelsFromString("db=debug, http=warn"))
+
+	pool.WithField("size", 5).Debug("resize")
+	db.Debug("query")
+	http.Info("request")
+	http.Warn("slow request")
+	l.Debug("hello")
+
+	assert.Len(t, h.Entries, 3)
+	assert.Equal(t, log.Fields{"logger": "db.pool", "size": 5}, h.Entries[0].Fields)
+	assert.Equal(t, log.Fields{"logger": "db"}, h.Entries[1].Fields)
+	assert.Equal(t, "slow request", h.Entries[2].Message)
+
+	log.SetNamedLevel("db.pool", log.ErrorLevel)
+	pool.Warn("exhausted")
+	db.Debug("query")
+	assert.Len(t, h.Entries, 4)
+
+	log.UnsetNamedLevel("db")
+	db.Debug("query")
+	db.Info("connected")
+	assert.Len(t, h.Entries, 5)
+	assert.Equal(t, map[string]log.Level{
+		"db.pool": log.ErrorLevel,
+		"http":    log.WarnLevel,
+	}, log.NamedLevels())
+
+	b := memory.New()
+	l.SetHandler(b)
+	db.Info("connected")
+	assert.Len(t, b.Entries, 1)
+
+	l.SetReportCaller(true)
+	pool.Error("exhausted")
+	assert.NotNil(t, b.Entries[1].Caller)
+
+	pool.SetReportCaller(false)
+	pool.Error("exhausted")
+	assert.Nil(t, b.Entries[2].Caller)
+}
+
+func TestParseNamedLevels(t *testing.T) {
+	m, err := log.ParseNamedLevels("db=debug,http.server=warning,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]log.Level{
+		"db":          log.DebugLevel,
+		"http.server": log.WarnLevel,
+	}, m)
+
+	_, err = log.ParseNamedLevels("db")
+	assert.Error(t, err)
+
+	_, err = log.ParseNamedLevels("db=loud")
+	assert.Error(t, err)
+}
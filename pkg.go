@@ -1,6 +1,9 @@
 package log
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // singletons ftw?
 var Log Interface = &Logger{
@@ -8,25 +11,59 @@ var Log Interface = &Logger{
 	Level:   InfoLevel,
 }
 
-// SetHandler sets the handler. This is not thread-safe.
+// SetHandler sets the handler. This is thread-safe.
 // The default handler outputs to the stdlib log.
 func SetHandler(h Handler) {
 	if logger, ok := Log.(*Logger); ok {
-		logger.Handler = h
+		logger.SetHandler(h)
 	}
 }
 
-// SetLevel sets the log level. This is not thread-safe.
+// SetLevel sets the log level. This is thread-safe.
 func SetLevel(l Level) {
 	if logger, ok := Log.(*Logger); ok {
-		logger.Level = l
+		logger.SetLevel(l)
 	}
 }
 
-// SetLevelFromString sets the log level from a string, panicing when invalid. This is not thread-safe.
+// SetLevelFromString sets the log level from a string, panicing when invalid. This is thread-safe.
 func SetLevelFromString(s string) {
 	if logger, ok := Log.(*Logger); ok {
-		logger.Level = MustParseLevel(s)
+		logger.SetLevel(MustParseLevel(s))
+	}
+}
+
+// Flush flushes the handler, waiting at most CloseTimeout.
+func Flush() error {
+	if logger, ok := Log.(*Logger); ok {
+		return logger.Flush()
+	}
+
+	return nil
+}
+
+// Close flushes and closes the handler, waiting at most CloseTimeout. This
+// should be called before exiting your program to ensure buffered entries
+// are written.
+func Close() error {
+	if logger, ok := Log.(*Logger); ok {
+		return logger.Close()
+	}
+
+	return nil
+}
+
+// SetErrorHandler sets the function called when the handler fails. This is thread-safe.
+func SetErrorHandler(fn func(error)) {
+	if logger, ok := Log.(*Logger); ok {
+		logger.SetErrorHandler(fn)
+	}
+}
+
+// SetReportCaller sets whether the caller is recorded. This is thread-safe.
+func SetReportCaller(v bool) {
+	if logger, ok := Log.(*Logger); ok {
+		logger.SetReportCaller(v)
 	}
 }
 
@@ -51,6 +88,19 @@ func WithError(err error) *Entry {
 	return Log.WithError(err)
 }
 
+// WithContext returns a new entry with the fields stored in `ctx`
+// and those returned by registered extractors. Implementations of
+// Interface without a WithContext method are passed the fields instead.
+func WithContext(ctx context.Context) *Entry {
+	if l, ok := Log.(interface {
+		WithContext(context.Context) *Entry
+	}); ok {
+		return l.WithContext(ctx)
+	}
+
+	return Log.WithFields(contextFields(ctx))
+}
+
 // Debug level message.
 func Debug(msg string) {
 	Log.Debug(msg)
@@ -2,6 +2,7 @@ package log_test
 
 import (
 	"errors"
+	"runtime"
 	"testing"
 
 	"github.com/apex/log"
@@ -43,6 +44,21 @@ func TestFielder(t *testing.T) {
 	assert.Equal(t, log.Fields{"name": "Tobi", "age": 3}, e.Fields)
 }
 
+func TestReportCaller(t *testing.T) {
+	h := memory.New()
+	log.SetHandler(h)
+	log.SetReportCaller(true)
+	defer log.SetReportCaller(false)
+
+	_, _, line, _ := runtime.Caller(0)
+	log.Info("upload")
+	log.WithField("file", "sloth.png").Errorf("upload %s", "failed")
+
+	assert.Equal(t, line+1, h.Entries[0].Caller.Line)
+	assert.Equal(t, line+2, h.Entries[1].Caller.Line)
+	assert.Equal(t, "github.com/apex/log_test.TestReportCaller", h.Entries[1].Caller.Function)
+}
+
 // Unstructured logging is supported, but not recommended since it is hard to query.
 func Example_unstructured() {
 	log.Infof("%s logged in", "Tobi")
@@ -1,8 +1,94 @@
 package log
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // stackTracer interface.
 type stackTracer interface {
 	StackTrace() errors.StackTrace
 }
+
+// wrapper interface.
+type wrapper interface {
+	Unwrap() error
+}
+
+// multiWrapper interface, implemented by errors.Join and similar.
+type multiWrapper interface {
+	Unwrap() []error
+}
+
+// walkErrors calls fn for `err` and each error it wraps, depth-first.
+func walkErrors(err error, fn func(error)) {
+	if err == nil {
+		return
+	}
+
+	fn(err)
+
+	switch v := err.(type) {
+	case multiWrapper:
+		for _, err := range v.Unwrap() {
+			walkErrors(err, fn)
+		}
+	case wrapper:
+		walkErrors(v.Unwrap(), fn)
+	}
+}
+
+// stackOf returns the innermost stack tracer in the wrap chain of `err`.
+func stackOf(err error) (s stackTracer) {
+	for err != nil {
+		if v, ok := err.(stackTracer); ok {
+			s = v
+		}
+
+		w, ok := err.(wrapper)
+		if !ok {
+			break
+		}
+
+		err = w.Unwrap()
+	}
+
+	return
+}
+
+// causesOf returns the errors wrapped by the first multi-error in the
+// wrap chain of `err`.
+func causesOf(err error) []error {
+	for err != nil {
+		switch v := err.(type) {
+		case multiWrapper:
+			return v.Unwrap()
+		case wrapper:
+			err = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// frameOf returns the Frame for a stack trace frame.
+func frameOf(f errors.Frame) Frame {
+	parts := strings.Split(fmt.Sprintf("%+s", f), "\n\t")
+	line, _ := strconv.Atoi(fmt.Sprintf("%d", f))
+
+	frame := Frame{
+		Function: parts[0],
+		Line:     line,
+	}
+
+	if len(parts) > 1 {
+		frame.File = parts[1]
+	}
+
+	return frame
+}